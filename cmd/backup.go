@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/sherifabdlnaby/rubban/rubban"
+	"github.com/spf13/cobra"
+)
+
+// backupCmd represents the backup command
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Export Kibana's saved objects to a new snapshot",
+	Long: `Export saved objects of the configured types (backup.types) to a new NDJSON snapshot in backup.path,
+then delete snapshots but the newest backup.keep ones.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(rubban.Backup(os.Stdout))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/sherifabdlnaby/rubban/rubban"
+	"github.com/spf13/cobra"
+)
+
+var diffOutput string
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare configuration with Kibana's index patterns",
+	Long: `Compare what enabled tasks say should exist with Kibana's index patterns, without changing anything.
+Prints missing and unexpected index patterns, index patterns with a wrong time field, and duplicates.
+Exits with 0 if there is no drift, 1 if there is, and 2 on errors.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(rubban.Diff(os.Stdout, diffOutput))
+	},
+}
+
+func init() {
+	diffCmd.Flags().StringVarP(&diffOutput, "output", "o", "text", "output format, text or json")
+	rootCmd.AddCommand(diffCmd)
+}
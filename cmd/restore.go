@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/sherifabdlnaby/rubban/rubban"
+	"github.com/spf13/cobra"
+)
+
+var restoreList bool
+
+// restoreCmd represents the restore command
+var restoreCmd = &cobra.Command{
+	Use:   "restore <snapshot>",
+	Short: "Import Kibana's saved objects from a snapshot",
+	Long: `Import saved objects from a snapshot in backup.path (or a path to an NDJSON file), overwriting existing ones.
+Use "latest" to restore the newest snapshot, and --list to list snapshots.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if restoreList {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		snapshot := ""
+		if len(args) == 1 {
+			snapshot = args[0]
+		}
+		os.Exit(rubban.Restore(os.Stdout, snapshot, restoreList))
+	},
+}
+
+func init() {
+	restoreCmd.Flags().BoolVarP(&restoreList, "list", "l", false, "list snapshots instead of restoring")
+	rootCmd.AddCommand(restoreCmd)
+}
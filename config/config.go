@@ -1,11 +1,29 @@
 package config
 
+import "time"
+
 //Config for Config Unmarshalling
 type Config struct {
-	Kibana              Kibana  `validate:"required"`
-	Logging             Logging `validate:"required"`
-	AutoIndexPattern    AutoIndexPattern
-	RefreshIndexPattern RefreshIndexPattern
+	Kibana                  Kibana  `validate:"required"`
+	Logging                 Logging `validate:"required"`
+	HTTP                    HTTP
+	LeaderElection          LeaderElection
+	Store                   Store
+	Audit                   Audit
+	Notify                  Notify
+	AutoIndexPattern        AutoIndexPattern
+	RefreshIndexPattern     RefreshIndexPattern
+	DefaultIndexPattern     DefaultIndexPattern
+	GitOps                  GitOps
+	ConsolidateIndexPattern ConsolidateIndexPattern
+	Backup                  Backup
+	WatchConfig             bool
+	file                    string
+}
+
+//File Return path of the configuration file the Config was loaded from.
+func (c *Config) File() string {
+	return c.file
 }
 
 //Kibana for Config Unmarshalling
@@ -13,12 +31,128 @@ type Kibana struct {
 	Host     string `validate:"required,uri"`
 	User     string `validate:"required_with=password"`
 	Password string `validate:"required_with=User"`
+	Space    string
+}
+
+//HTTP for Config Unmarshalling
+type HTTP struct {
+	Enabled bool
+	Address string `validate:"required"`
+	Token   string
+	Admin   bool
+}
+
+//LeaderElection for Config Unmarshalling
+type LeaderElection struct {
+	Enabled       bool
+	Backend       string `validate:"oneof=file kubernetes elasticsearch"`
+	Identity      string
+	LeaseDuration time.Duration `validate:"gt=0"`
+	RenewDeadline time.Duration `validate:"gtfield=RetryPeriod,ltfield=LeaseDuration"`
+	RetryPeriod   time.Duration `validate:"gt=0,ltfield=LeaseDuration"`
+	File          LeaderElectionFile
+	Kubernetes    LeaderElectionKubernetes
+	Elasticsearch LeaderElectionElasticsearch
+}
+
+//LeaderElectionFile for Config Unmarshalling
+type LeaderElectionFile struct {
+	Path string `validate:"required"`
+}
+
+//LeaderElectionKubernetes for Config Unmarshalling
+type LeaderElectionKubernetes struct {
+	Namespace string
+	Name      string `validate:"required"`
+}
+
+//LeaderElectionElasticsearch for Config Unmarshalling
+type LeaderElectionElasticsearch struct {
+	Index string `validate:"required"`
+	ID    string `validate:"required"`
+}
+
+//Store for Config Unmarshalling
+type Store struct {
+	Enabled       bool
+	Backend       string `validate:"oneof=file elasticsearch"`
+	Retention     time.Duration
+	File          StoreFile
+	Elasticsearch StoreElasticsearch
+}
+
+//StoreFile for Config Unmarshalling
+type StoreFile struct {
+	Path string `validate:"required"`
+}
+
+//StoreElasticsearch for Config Unmarshalling
+type StoreElasticsearch struct {
+	Index string `validate:"required"`
+}
+
+//Audit for Config Unmarshalling
+type Audit struct {
+	Enabled       bool
+	Backend       string `validate:"oneof=file elasticsearch"`
+	File          AuditFile
+	Elasticsearch AuditElasticsearch
+}
+
+//AuditFile for Config Unmarshalling
+type AuditFile struct {
+	Path string `validate:"required"`
+}
+
+//AuditElasticsearch for Config Unmarshalling
+type AuditElasticsearch struct {
+	Index string `validate:"required"`
+}
+
+//Notify for Config Unmarshalling
+type Notify struct {
+	Enabled   bool
+	RateLimit time.Duration
+	Notifiers []Notifier `validate:"dive"`
+}
+
+//Notifier for Config Unmarshalling
+type Notifier struct {
+	Type   string `validate:"oneof=webhook slack smtp"`
+	URL    string `validate:"omitempty,url"`
+	Tasks  []string
+	Events []string `validate:"dive,oneof=changes failures unreachable"`
+	SMTP   SMTP
+}
+
+//SMTP for Config Unmarshalling
+type SMTP struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
 }
 
 //GeneralPattern for Config Unmarshalling
 type GeneralPattern struct {
-	Pattern       string `validate:"required"`
-	TimeFieldName string
+	Pattern             string `validate:"required"`
+	TimeFieldName       string
+	TimeFieldCandidates []string
+	IDTemplate          string
+	NameTemplate        string
+	DashboardTemplate   string
+	RuleTemplate        string
+	Attributes          IndexPatternAttributes
+}
+
+//IndexPatternAttributes for Config Unmarshalling
+type IndexPatternAttributes struct {
+	FieldFormatMap  map[string]interface{}
+	SourceFilters   []string
+	RuntimeFieldMap map[string]interface{}
+	AllowNoIndex    bool
 }
 
 //AutoIndexPattern for Config Unmarshalling
@@ -27,6 +161,32 @@ type AutoIndexPattern struct {
 	GeneralPatterns []GeneralPattern
 	Schedule        string `validate:"required"`
 	Concurrency     int    `validate:"gt=0"`
+	Watch           Watch
+	Elasticsearch   AutoIndexPatternElasticsearch
+	TaskRun         `mapstructure:",squash"`
+}
+
+//AutoIndexPatternElasticsearch for Config Unmarshalling
+type AutoIndexPatternElasticsearch struct {
+	Index string `validate:"required"`
+	ID    string `validate:"required"`
+}
+
+//TaskRun for Config Unmarshalling
+type TaskRun struct {
+	Timeout    time.Duration `validate:"gte=0"`
+	Overlap    string        `validate:"oneof=skip delay allow"`
+	Jitter     time.Duration `validate:"gte=0"`
+	RunOnStart bool
+	StartDelay time.Duration `validate:"gte=0"`
+	Timezone   string
+}
+
+//Watch for Config Unmarshalling
+type Watch struct {
+	ClusterState bool
+	Interval     time.Duration `validate:"gt=0"`
+	Webhook      bool
 }
 
 //RefreshIndexPattern for Config Unmarshalling
@@ -35,6 +195,52 @@ type RefreshIndexPattern struct {
 	Patterns    []string
 	Schedule    string `validate:"required"`
 	Concurrency int    `validate:"gt=0"`
+	TaskRun     `mapstructure:",squash"`
+}
+
+//DefaultIndexPattern for Config Unmarshalling
+type DefaultIndexPattern struct {
+	Enabled       bool
+	Title         string
+	ID            string
+	TimeFieldName string
+	Schedule      string `validate:"required"`
+	TaskRun       `mapstructure:",squash"`
+}
+
+//GitOps for Config Unmarshalling
+type GitOps struct {
+	Enabled       bool
+	Path          string `validate:"required"`
+	Prune         bool
+	Elasticsearch GitOpsElasticsearch
+	Schedule      string `validate:"required"`
+	TaskRun       `mapstructure:",squash"`
+}
+
+//GitOpsElasticsearch for Config Unmarshalling
+type GitOpsElasticsearch struct {
+	Index string `validate:"required"`
+	ID    string `validate:"required"`
+}
+
+//ConsolidateIndexPattern for Config Unmarshalling
+type ConsolidateIndexPattern struct {
+	Enabled        bool
+	Merge          string `validate:"oneof=none duplicates all"`
+	ReferenceTypes []string
+	Schedule       string `validate:"required"`
+	TaskRun        `mapstructure:",squash"`
+}
+
+//Backup for Config Unmarshalling
+type Backup struct {
+	Enabled  bool
+	Path     string   `validate:"required"`
+	Types    []string `validate:"required,min=1"`
+	Keep     int      `validate:"min=0"`
+	Schedule string   `validate:"required"`
+	TaskRun  `mapstructure:",squash"`
 }
 
 //Logging for Config Unmarshalling
@@ -59,17 +265,85 @@ func Default() *Config {
 			Debug:  false,
 			Color:  false,
 		},
+		HTTP: HTTP{
+			Enabled: false,
+			Address: ":8080",
+		},
+		LeaderElection: LeaderElection{
+			Enabled:       false,
+			Backend:       "file",
+			LeaseDuration: 15 * time.Second,
+			RenewDeadline: 10 * time.Second,
+			RetryPeriod:   5 * time.Second,
+			File:          LeaderElectionFile{Path: "./rubban.lock"},
+			Kubernetes:    LeaderElectionKubernetes{Name: "rubban"},
+			Elasticsearch: LeaderElectionElasticsearch{Index: ".rubban", ID: "leader"},
+		},
+		Store: Store{
+			Enabled:       false,
+			Backend:       "file",
+			Retention:     30 * 24 * time.Hour,
+			File:          StoreFile{Path: "./rubban.db"},
+			Elasticsearch: StoreElasticsearch{Index: ".rubban-runs"},
+		},
+		Audit: Audit{
+			Enabled:       false,
+			Backend:       "file",
+			File:          AuditFile{Path: "./rubban-audit.log"},
+			Elasticsearch: AuditElasticsearch{Index: ".rubban-audit"},
+		},
+		Notify: Notify{
+			Enabled:   false,
+			RateLimit: 15 * time.Minute,
+		},
 		AutoIndexPattern: AutoIndexPattern{
 			Enabled:         false,
 			GeneralPatterns: nil,
 			Schedule:        "*/5 * * * *",
 			Concurrency:     20,
+			Watch: Watch{
+				ClusterState: false,
+				Interval:     10 * time.Second,
+				Webhook:      false,
+			},
+			Elasticsearch: AutoIndexPatternElasticsearch{Index: ".rubban", ID: "rules"},
+			TaskRun:       TaskRun{Overlap: "delay"},
 		},
 		RefreshIndexPattern: RefreshIndexPattern{
 			Enabled:     false,
 			Patterns:    nil,
 			Schedule:    "*/5 * * * *",
 			Concurrency: 20,
+			TaskRun:     TaskRun{Overlap: "delay"},
+		},
+		DefaultIndexPattern: DefaultIndexPattern{
+			Enabled:  false,
+			Schedule: "*/5 * * * *",
+			TaskRun:  TaskRun{Overlap: "delay"},
+		},
+		GitOps: GitOps{
+			Enabled:       false,
+			Path:          "./index-patterns",
+			Prune:         false,
+			Elasticsearch: GitOpsElasticsearch{Index: ".rubban", ID: "gitops"},
+			Schedule:      "*/5 * * * *",
+			TaskRun:       TaskRun{Overlap: "delay"},
+		},
+		ConsolidateIndexPattern: ConsolidateIndexPattern{
+			Enabled:        false,
+			Merge:          "none",
+			ReferenceTypes: []string{"visualization", "search", "dashboard"},
+			Schedule:       "0 * * * *",
+			TaskRun:        TaskRun{Overlap: "skip"},
+		},
+		Backup: Backup{
+			Enabled:  false,
+			Path:     "./backups",
+			Types:    []string{"index-pattern"},
+			Keep:     7,
+			Schedule: "0 0 * * *",
+			TaskRun:  TaskRun{Overlap: "skip"},
 		},
+		WatchConfig: true,
 	}
 }
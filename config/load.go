@@ -9,6 +9,7 @@ import (
 
 	"github.com/joho/godotenv"
 	"github.com/mitchellh/mapstructure"
+	"github.com/sherifabdlnaby/rubban/rubban/utils"
 	"github.com/spf13/viper"
 )
 
@@ -60,6 +61,7 @@ func Load(configName string) (*Config, error) {
 		mapstructure.StringToTimeDurationHookFunc(),
 		mapstructure.StringToIPHookFunc(),
 		StringJSONArrayOrSlicesToConfig(),
+		StringifyMapKeys(),
 	)))
 
 	if err != nil {
@@ -72,9 +74,25 @@ func Load(configName string) (*Config, error) {
 		return nil, err
 	}
 
+	cfg.file = v.ConfigFileUsed()
+
 	return cfg, nil
 }
 
+//StringifyMapKeys will convert maps with interface{} keys (as decoded from YAML) to maps with string keys, so that
+//free-form config values (e.g Index Pattern Attributes) can be JSON encoded later.
+func StringifyMapKeys() func(f reflect.Kind, t reflect.Kind, data interface{}) (interface{}, error) {
+	return func(
+		f reflect.Kind,
+		t reflect.Kind,
+		data interface{}) (interface{}, error) {
+		if f != reflect.Map && f != reflect.Slice {
+			return data, nil
+		}
+		return utils.StringifyMapKeys(data), nil
+	}
+}
+
 //StringJSONArrayOrSlicesToConfig will convert Json Encoded Strings to Maps or Slices, Used Primarily to support Slices and Maps in Environment variables
 func StringJSONArrayOrSlicesToConfig() func(f reflect.Kind, t reflect.Kind, data interface{}) (interface{}, error) {
 	return func(
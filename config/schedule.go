@@ -0,0 +1,28 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// scheduleParser parses cron expressions with an optional seconds field (6 fields), descriptors (e.g @daily), and a
+// CRON_TZ= (or TZ=) prefix.
+var scheduleParser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+//ParseSchedule Parse a task's schedule in timezone (local time if empty), a schedule with a CRON_TZ= (or TZ=) prefix
+//can't also have a timezone. Configuration is validated using the same parser.
+func ParseSchedule(schedule, timezone string) (cron.Schedule, error) {
+	if timezone != "" {
+		if strings.HasPrefix(schedule, "CRON_TZ=") || strings.HasPrefix(schedule, "TZ=") {
+			return nil, fmt.Errorf("schedule [%s] has a timezone prefix, it can't also have timezone [%s]", schedule, timezone)
+		}
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return nil, fmt.Errorf("invalid timezone [%s]: %s", timezone, err.Error())
+		}
+		schedule = fmt.Sprintf("CRON_TZ=%s %s", timezone, schedule)
+	}
+	return scheduleParser.Parse(schedule)
+}
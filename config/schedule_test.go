@@ -0,0 +1,47 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	from := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		schedule string
+		timezone string
+		next     time.Time
+		invalid  bool
+	}{
+		{schedule: "*/5 * * * *", next: from.Add(5 * time.Minute)},
+		{schedule: "*/30 * * * * *", next: from.Add(30 * time.Second)},
+		{schedule: "@hourly", next: from.Add(time.Hour)},
+		{schedule: "0 2 * * *", timezone: "Africa/Cairo", next: from.Add(24 * time.Hour)},
+		{schedule: "CRON_TZ=Africa/Cairo 0 2 * * *", next: from.Add(24 * time.Hour)},
+		{schedule: "CRON_TZ=UTC 0 2 * * *", timezone: "Africa/Cairo", invalid: true},
+		{schedule: "TZ=UTC 0 2 * * *", timezone: "Africa/Cairo", invalid: true},
+		{schedule: "0 2 * * *", timezone: "Nowhere/Nothing", invalid: true},
+		{schedule: "* * * *", invalid: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.schedule+" "+test.timezone, func(t *testing.T) {
+			schedule, err := ParseSchedule(test.schedule, test.timezone)
+			if test.invalid {
+				if err == nil {
+					t.Errorf("Expected schedule to be invalid")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			next := schedule.Next(from)
+			if !next.Equal(test.next) {
+				t.Errorf("Expected next run at %s, got %s", test.next, next)
+			}
+		})
+	}
+}
@@ -1,13 +1,17 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/go-playground/locales/en"
 	ut "github.com/go-playground/universal-translator"
 	ens "github.com/go-playground/validator/translations/en"
-	"github.com/robfig/cron/v3"
 	"gopkg.in/go-playground/validator.v9"
 )
 
@@ -51,38 +55,168 @@ func customValidate(config Config) error {
 		}
 	}
 
+	if config.AutoIndexPattern.Watch.Webhook && !config.HTTP.Enabled {
+		return fmt.Errorf("http server must be enabled to use Auto Index Pattern's webhook. ")
+	}
+
+	if config.HTTP.Admin && (!config.HTTP.Enabled || config.HTTP.Token == "") {
+		return fmt.Errorf("http server must be enabled with a token to use the admin API. ")
+	}
+
+	if config.AutoIndexPattern.Watch.ClusterState && config.AutoIndexPattern.Watch.Interval < time.Second {
+		return fmt.Errorf("auto index pattern's watch interval must be at least 1s. ")
+	}
+
 	for _, pattern := range config.RefreshIndexPattern.Patterns {
-		if strings.ContainsAny(pattern, "/\\#\"?<>| ,") || !validIndexPattern(pattern) {
+		if !validPattern(pattern, "/\\#\"?<>| ,") {
 			return fmt.Errorf("invalid pattern [%s]", pattern)
 		}
 	}
 
 	for _, generalPattern := range config.AutoIndexPattern.GeneralPatterns {
 		pattern := generalPattern.Pattern
-		if strings.ContainsAny(pattern, "/\\#\"<>| ,") || !validIndexPattern(pattern) ||
+		if !validPattern(pattern, "/\\#\"<>| ,") ||
 			strings.Contains(pattern, "**") ||
 			strings.Contains(pattern, "??") {
 			return fmt.Errorf("invalid general pattern [%s]", pattern)
 		}
+
+		if generalPattern.TimeFieldName != "" && len(generalPattern.TimeFieldCandidates) > 0 {
+			return fmt.Errorf("general pattern [%s] can't have both timeFieldName and timeFieldCandidates", pattern)
+		}
+
+		if _, err := json.Marshal(generalPattern.Attributes.FieldFormatMap); err != nil {
+			return fmt.Errorf("invalid fieldFormatMap for general pattern [%s]: %s", pattern, err.Error())
+		}
+
+		if _, err := json.Marshal(generalPattern.Attributes.RuntimeFieldMap); err != nil {
+			return fmt.Errorf("invalid runtimeFieldMap for general pattern [%s]: %s", pattern, err.Error())
+		}
+
+		if err := validateTemplate("id", generalPattern.IDTemplate, pattern); err != nil {
+			return fmt.Errorf("invalid id template for general pattern [%s]: %s", pattern, err.Error())
+		}
+
+		if err := validateTemplate("name", generalPattern.NameTemplate, pattern); err != nil {
+			return fmt.Errorf("invalid name template for general pattern [%s]: %s", pattern, err.Error())
+		}
+
+		if generalPattern.DashboardTemplate != "" {
+			if generalPattern.IDTemplate == "" {
+				return fmt.Errorf("general pattern [%s] needs an id template to use a dashboard template", pattern)
+			}
+			if _, err := os.Stat(generalPattern.DashboardTemplate); err != nil {
+				return fmt.Errorf("invalid dashboard template for general pattern [%s]: %s", pattern, err.Error())
+			}
+		}
+
+		if generalPattern.RuleTemplate != "" {
+			if generalPattern.IDTemplate == "" {
+				return fmt.Errorf("general pattern [%s] needs an id template to use a rule template", pattern)
+			}
+			if _, err := template.ParseFiles(generalPattern.RuleTemplate); err != nil {
+				return fmt.Errorf("invalid rule template for general pattern [%s]: %s", pattern, err.Error())
+			}
+		}
+	}
+
+	for i, notifier := range config.Notify.Notifiers {
+		switch notifier.Type {
+		case "webhook", "slack":
+			if notifier.URL == "" {
+				return fmt.Errorf("notifier [%d] of type %s needs a url. ", i, notifier.Type)
+			}
+		case "smtp":
+			if notifier.SMTP.Host == "" || notifier.SMTP.Port == 0 || notifier.SMTP.From == "" || len(notifier.SMTP.To) == 0 {
+				return fmt.Errorf("notifier [%d] of type smtp needs a host, port, from and to. ", i)
+			}
+		}
+	}
+
+	if config.ConsolidateIndexPattern.Merge != "none" && len(config.ConsolidateIndexPattern.ReferenceTypes) == 0 {
+		return fmt.Errorf("reference types are needed to merge index patterns. ")
+	}
+
+	if config.DefaultIndexPattern.Enabled {
+		title := config.DefaultIndexPattern.Title
+		if title == "" && config.DefaultIndexPattern.ID == "" {
+			return fmt.Errorf("a title or an id is needed for Default Index Pattern. ")
+		}
+		if title != "" && !validPattern(title, "/\\#\"?<>| ") {
+			return fmt.Errorf("invalid default index pattern title [%s]", title)
+		}
 	}
 
 	// validate cron schedules
-	_, err := cron.ParseStandard(config.AutoIndexPattern.Schedule)
+	_, err := ParseSchedule(config.AutoIndexPattern.Schedule, config.AutoIndexPattern.Timezone)
 	if err != nil {
 		return fmt.Errorf("autoindexpattern's cron expression not valid: %s", err.Error())
 	}
 
-	_, err = cron.ParseStandard(config.RefreshIndexPattern.Schedule)
+	_, err = ParseSchedule(config.RefreshIndexPattern.Schedule, config.RefreshIndexPattern.Timezone)
 	if err != nil {
 		return fmt.Errorf("refreshindexpattern's cron expression not valid: %s", err.Error())
 	}
 
+	_, err = ParseSchedule(config.DefaultIndexPattern.Schedule, config.DefaultIndexPattern.Timezone)
+	if err != nil {
+		return fmt.Errorf("defaultindexpattern's cron expression not valid: %s", err.Error())
+	}
+
+	_, err = ParseSchedule(config.GitOps.Schedule, config.GitOps.Timezone)
+	if err != nil {
+		return fmt.Errorf("gitops's cron expression not valid: %s", err.Error())
+	}
+
+	_, err = ParseSchedule(config.ConsolidateIndexPattern.Schedule, config.ConsolidateIndexPattern.Timezone)
+	if err != nil {
+		return fmt.Errorf("consolidateindexpattern's cron expression not valid: %s", err.Error())
+	}
+
+	_, err = ParseSchedule(config.Backup.Schedule, config.Backup.Timezone)
+	if err != nil {
+		return fmt.Errorf("backup's cron expression not valid: %s", err.Error())
+	}
+
 	return nil
 }
 
+// validPattern check a pattern of local indices (logs-*) or remote clusters' indices (cluster_a:logs-*, *:logs-*), neither
+// the cluster nor the index part can contain any of forbidden chars.
+func validPattern(pattern string, forbidden string) bool {
+	if i := strings.Index(pattern, ":"); i != -1 {
+		cluster := pattern[:i]
+		if cluster == "" || strings.ContainsAny(cluster, forbidden) {
+			return false
+		}
+		pattern = pattern[i+1:]
+	}
+	return !strings.ContainsAny(pattern, forbidden+":") && validIndexPattern(pattern)
+}
+
 func validIndexPattern(pattern string) bool {
 	return len(pattern) <= 255 && pattern != "." &&
 		pattern != ".." && !strings.HasPrefix(pattern, "-") &&
 		!strings.HasPrefix(pattern, "_") && !strings.HasPrefix(pattern, "+") &&
 		pattern == strings.ToLower(pattern)
 }
+
+// validateTemplate parse an id or name template and execute it against sample data of the general pattern (title,
+// generalPattern and a groupN per '?' of the pattern), so a template referencing a missing key fails validation instead
+// of every run.
+func validateTemplate(name, text, pattern string) error {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return err
+	}
+
+	data := map[string]string{
+		"title":          pattern,
+		"generalPattern": pattern,
+	}
+	for i := 1; i <= strings.Count(pattern, "?"); i++ {
+		data[fmt.Sprintf("group%d", i)] = fmt.Sprintf("group%d", i)
+	}
+
+	return tmpl.Execute(ioutil.Discard, data)
+}
@@ -0,0 +1,66 @@
+package config
+
+import "testing"
+
+func TestValidPattern(t *testing.T) {
+	for pattern, valid := range map[string]bool{
+		"logs-?-*":             true,
+		"cluster_a:logs-?-*":   true,
+		"Cluster-A:logs-*":     true,
+		"*:logs-*":             true,
+		"?:logs-?-*":           true,
+		":logs-*":              false,
+		"cluster_a:Logs-*":     false,
+		"cluster_a:_logs-*":    false,
+		"cluster_a:logs:x-*":   false,
+		"cluster a:logs-*":     false,
+		"cluster_a:logs-*,x-*": false,
+	} {
+		if actual := validPattern(pattern, "/\\#\"<>| ,"); actual != valid {
+			t.Errorf("Expected pattern [%s] valid to be %t, got %t", pattern, valid, actual)
+		}
+	}
+}
+
+func TestValidateHTTP(t *testing.T) {
+	for _, tcase := range []struct {
+		name  string
+		http  HTTP
+		valid bool
+	}{
+		{name: "open server", http: HTTP{Enabled: true}, valid: true},
+		{name: "admin with token", http: HTTP{Enabled: true, Token: "secret", Admin: true}, valid: true},
+		{name: "open admin", http: HTTP{Enabled: true, Admin: true}, valid: false},
+		{name: "admin without server", http: HTTP{Token: "secret", Admin: true}, valid: false},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			config := *Default()
+			config.HTTP = tcase.http
+			if err := customValidate(config); (err == nil) != tcase.valid {
+				t.Errorf("expected valid to be %t, got error %v", tcase.valid, err)
+			}
+		})
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	for _, tcase := range []struct {
+		name     string
+		template string
+		pattern  string
+		valid    bool
+	}{
+		{name: "empty", template: "", pattern: "logs-?-?-*", valid: true},
+		{name: "groups", template: "{{.group1}}-{{.group2}}", pattern: "logs-?-?-*", valid: true},
+		{name: "title", template: "{{.title}} of {{.generalPattern}}", pattern: "logs-*", valid: true},
+		{name: "missing group", template: "{{.group3}}", pattern: "logs-?-?-*", valid: false},
+		{name: "unknown key", template: "{{.group}}", pattern: "logs-?-*", valid: false},
+		{name: "unparsable", template: "{{.group1", pattern: "logs-?-*", valid: false},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			if err := validateTemplate("id", tcase.template, tcase.pattern); (err == nil) != tcase.valid {
+				t.Errorf("expected valid to be %t, got error %v", tcase.valid, err)
+			}
+		})
+	}
+}
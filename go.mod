@@ -7,6 +7,7 @@ replace github.com/census-instrumentation/opencensus-proto v0.1.0-0.201812141439
 require (
 	github.com/Masterminds/semver/v3 v3.0.3
 	github.com/dustin/go-humanize v1.0.0
+	github.com/fsnotify/fsnotify v1.4.7
 	github.com/go-playground/locales v0.13.0
 	github.com/go-playground/universal-translator v0.17.0
 	github.com/go-playground/validator v9.31.0+incompatible
@@ -18,9 +19,10 @@ require (
 	github.com/spf13/cobra v0.0.5
 	github.com/spf13/viper v1.3.2
 	github.com/sykesm/zap-logfmt v0.0.3
+	go.etcd.io/bbolt v1.3.6
 	go.uber.org/atomic v1.5.0
 	go.uber.org/zap v1.13.0
-	golang.org/x/sys v0.0.0-20190422165155-953cdadca894 // indirect
 	gopkg.in/go-playground/assert.v1 v1.2.1 // indirect
 	gopkg.in/go-playground/validator.v9 v9.31.0
+	gopkg.in/yaml.v2 v2.2.2
 )
@@ -65,6 +65,11 @@ func NewZapLoggerImpl(name string, config config.Logging) Logger {
 	return &zapLogger{l: logger.Sugar().Named(name), doExtend: config.Format == json}
 }
 
+//Nop Return a Logger that discards everything
+func Nop() Logger {
+	return &zapLogger{l: zap.NewNop().Sugar()}
+}
+
 func (z *zapLogger) Extend(name string) Logger {
 	if z.doExtend {
 		return &zapLogger{l: z.l.Named(name)}
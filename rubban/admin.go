@@ -0,0 +1,85 @@
+package rubban
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/sherifabdlnaby/rubban/log"
+	"github.com/sherifabdlnaby/rubban/rubban/dryrun"
+	"github.com/sherifabdlnaby/rubban/rubban/server"
+	"github.com/sherifabdlnaby/rubban/rubban/store"
+)
+
+// handleTasks list registered tasks, their schedule and next run.
+func (r *Rubban) handleTasks(w http.ResponseWriter, req *http.Request) {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+	server.WriteJSON(w, http.StatusOK, r.state.scheduler.Tasks())
+}
+
+// handleTaskAction handle POST /tasks/{task}/{trigger|pause|resume}, task is matched ignoring case and spaces.
+func (r *Rubban) handleTaskAction(w http.ResponseWriter, req *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(req.URL.Path, "/tasks/"), "/"), "/")
+	if len(parts) != 2 {
+		server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	name, action := parts[0], parts[1]
+
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+
+	var err error
+	var status string
+	switch action {
+	case "trigger":
+		err, status = r.state.scheduler.Trigger(name), "triggered"
+	case "pause":
+		err, status = r.state.scheduler.Pause(name), "paused"
+	case "resume":
+		err, status = r.state.scheduler.Resume(name), "resumed"
+	default:
+		server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "unknown action " + action})
+		return
+	}
+
+	switch err {
+	case nil:
+		server.WriteJSON(w, http.StatusOK, map[string]string{"status": status})
+	case errTaskNotFound:
+		server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errTaskPaused:
+		server.WriteJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		server.WriteJSON(w, http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+	}
+}
+
+// handlePlan preview the changes enabled tasks would make to Kibana now, without making them.
+func (r *Rubban) handlePlan(w http.ResponseWriter, req *http.Request) {
+	server.WriteJSON(w, http.StatusOK, r.plan(req.Context()))
+}
+
+// plan Run enabled tasks against a dry-run API, and return the changes each would make.
+func (r *Rubban) plan(ctx context.Context) []store.Run {
+	r.mx.RLock()
+	current := r.state
+	r.mx.RUnlock()
+
+	s := &state{
+		config:  current.config,
+		semVer:  current.semVer,
+		api:     dryrun.NewAPI(current.api),
+		context: ctx,
+	}
+	r.initTasks(s, log.Nop())
+
+	runs := make([]store.Run, 0)
+	for _, task := range r.enabledTasks(s) {
+		recorder := store.NewRecorder(task.Name())
+		result := current.scheduler.runTask(store.WithRecorder(ctx, recorder), task)
+		runs = append(runs, recorder.Finish(result))
+	}
+	return runs
+}
@@ -0,0 +1,229 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sherifabdlnaby/rubban/log"
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+	"github.com/sherifabdlnaby/rubban/rubban/store"
+)
+
+//API wraps a kibana.API and writes every mutation made through it to an audit Log. (Documents Rubban keeps for
+//itself, like leader locks and runs, are not audited)
+type API struct {
+	kibana.API
+	log    Log
+	space  string
+	logger log.Logger
+}
+
+//NewAPI Constructor
+func NewAPI(api kibana.API, auditLog Log, space string, logger log.Logger) *API {
+	if space == "" {
+		space = "default"
+	}
+	return &API{API: api, log: auditLog, space: space, logger: logger}
+}
+
+//BulkCreateIndexPattern Add Index Patterns to Kibana, auditing each with its attributes before the change (if it
+//already existed) and after.
+func (a *API) BulkCreateIndexPattern(ctx context.Context, indexPatterns []kibana.IndexPattern) error {
+	if len(indexPatterns) == 0 {
+		return nil
+	}
+
+	// Get index patterns before overwriting them (index patterns without an ID are always created)
+	before := make([]*kibana.IndexPattern, len(indexPatterns))
+	for i, indexPattern := range indexPatterns {
+		if indexPattern.ID == "" {
+			continue
+		}
+		current, found, err := a.API.GetIndexPattern(ctx, indexPattern.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get index pattern [%s] before overwriting it, error: %s", indexPattern.ID, err.Error())
+		}
+		if found {
+			before[i] = &current
+		}
+	}
+
+	err := a.API.BulkCreateIndexPattern(ctx, indexPatterns)
+
+	for i := range indexPatterns {
+		event := Event{
+			Action:  ActionCreate,
+			Type:    "index-pattern",
+			ID:      indexPatterns[i].ID,
+			Title:   indexPatterns[i].Title,
+			After:   &indexPatterns[i],
+			Indices: indices(ctx, indexPatterns[i].Title),
+		}
+		if before[i] != nil {
+			event.Action = ActionOverwrite
+			event.Before = before[i]
+		}
+		a.write(ctx, event, err)
+	}
+
+	return err
+}
+
+//DeleteIndexPattern Delete IndexPattern by ID, auditing its attributes before deleting it.
+func (a *API) DeleteIndexPattern(ctx context.Context, id string) error {
+	current, found, err := a.API.GetIndexPattern(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get index pattern [%s] before deleting it, error: %s", id, err.Error())
+	}
+
+	// Nothing to delete
+	if !found {
+		return nil
+	}
+
+	err = a.API.DeleteIndexPattern(ctx, id)
+
+	a.write(ctx, Event{
+		Action: ActionDelete,
+		Type:   "index-pattern",
+		ID:     id,
+		Title:  current.Title,
+		Before: &current,
+	}, err)
+
+	return err
+}
+
+//UpdateSavedObject Update a SavedObject, auditing it before and after the update.
+func (a *API) UpdateSavedObject(ctx context.Context, object kibana.SavedObject) error {
+	current, found, err := a.API.GetSavedObject(ctx, object.Type, object.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get saved object [%s/%s] before updating it, error: %s", object.Type, object.ID, err.Error())
+	}
+
+	err = a.API.UpdateSavedObject(ctx, object)
+
+	event := Event{
+		Action: ActionCreate,
+		Type:   object.Type,
+		ID:     object.ID,
+		Title:  object.Title(),
+		After:  &object,
+	}
+	if found {
+		event.Action = ActionOverwrite
+		event.Title = current.Title()
+		event.Before = &current
+	}
+	a.write(ctx, event, err)
+
+	return err
+}
+
+//ImportSavedObjects Import SavedObjects from NDJSON, auditing each imported object with its attributes after the import.
+func (a *API) ImportSavedObjects(ctx context.Context, ndjson []byte, overwrite bool) (kibana.ImportResult, error) {
+	objects, err := kibana.DecodeSavedObjects(ndjson)
+	if err != nil {
+		return kibana.ImportResult{}, err
+	}
+
+	result, err := a.API.ImportSavedObjects(ctx, ndjson, overwrite)
+
+	failed := make(map[string]string)
+	for _, importError := range result.Errors {
+		failed[importError.Type+"/"+importError.ID] = importError.Error.Type
+	}
+
+	for i := range objects {
+		objectErr := err
+		if errorType, ok := failed[objects[i].Type+"/"+objects[i].ID]; ok && objectErr == nil {
+			objectErr = fmt.Errorf("failed to import saved object, error: %s", errorType)
+		}
+		a.write(ctx, Event{
+			Action: ActionImport,
+			Type:   objects[i].Type,
+			ID:     objects[i].ID,
+			Title:  objects[i].Title(),
+			After:  &objects[i],
+		}, objectErr)
+	}
+
+	return result, err
+}
+
+//CreateRule Create an alerting Rule with ID, auditing it if it was created.
+func (a *API) CreateRule(ctx context.Context, id string, rule json.RawMessage) (bool, error) {
+	created, err := a.API.CreateRule(ctx, id, rule)
+	if created || err != nil {
+		a.write(ctx, Event{
+			Action: ActionCreate,
+			Type:   "rule",
+			ID:     id,
+			Title:  ruleName(rule),
+			After:  rule,
+		}, err)
+	}
+	return created, err
+}
+
+//DeleteRule Delete an alerting Rule by ID, auditing it.
+func (a *API) DeleteRule(ctx context.Context, id string) error {
+	err := a.API.DeleteRule(ctx, id)
+	a.write(ctx, Event{
+		Action: ActionDelete,
+		Type:   "rule",
+		ID:     id,
+	}, err)
+	return err
+}
+
+//SetDefaultIndexPattern Set Default IndexPattern by ID, auditing the previous default (if any) and the new one.
+func (a *API) SetDefaultIndexPattern(ctx context.Context, id string) error {
+	current, err := a.API.DefaultIndexPattern(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get default index pattern before setting it, error: %s", err.Error())
+	}
+
+	err = a.API.SetDefaultIndexPattern(ctx, id)
+
+	event := Event{
+		Action: ActionCreate,
+		Type:   "config",
+		ID:     "defaultIndex",
+		After:  id,
+	}
+	if current != "" {
+		event.Action = ActionOverwrite
+		event.Before = current
+	}
+	a.write(ctx, event, err)
+
+	return err
+}
+
+func ruleName(rule json.RawMessage) string {
+	attributes := struct {
+		Name string `json:"name"`
+	}{}
+	_ = json.Unmarshal(rule, &attributes)
+	return attributes.Name
+}
+
+func (a *API) write(ctx context.Context, event Event, err error) {
+	event.Timestamp = time.Now().UTC()
+	event.Task = store.Task(ctx)
+	event.Space = a.space
+	if err != nil {
+		event.Error = err.Error()
+	}
+
+	// Write even if ctx is canceled, the mutation already happened.
+	writeCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := a.log.Write(writeCtx, event); err != nil {
+		a.logger.Errorw("Failed to write audit event", "event", event, "error", err.Error())
+	}
+}
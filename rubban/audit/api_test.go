@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sherifabdlnaby/rubban/log"
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+	"github.com/sherifabdlnaby/rubban/rubban/store"
+)
+
+type mockAPI struct {
+	kibana.API
+	indexPatterns map[string]kibana.IndexPattern
+	defaultIndex  string
+}
+
+func (m *mockAPI) GetIndexPattern(ctx context.Context, id string) (kibana.IndexPattern, bool, error) {
+	indexPattern, ok := m.indexPatterns[id]
+	return indexPattern, ok, nil
+}
+
+func (m *mockAPI) BulkCreateIndexPattern(ctx context.Context, indexPatterns []kibana.IndexPattern) error {
+	for _, indexPattern := range indexPatterns {
+		m.indexPatterns[indexPattern.ID] = indexPattern
+	}
+	return nil
+}
+
+func (m *mockAPI) DefaultIndexPattern(ctx context.Context) (string, error) {
+	return m.defaultIndex, nil
+}
+
+func (m *mockAPI) SetDefaultIndexPattern(ctx context.Context, id string) error {
+	m.defaultIndex = id
+	return nil
+}
+
+type mockLog struct {
+	events []Event
+}
+
+func (m *mockLog) Write(ctx context.Context, event Event) error {
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *mockLog) Close() error {
+	return nil
+}
+
+func TestAuditAPI(t *testing.T) {
+	existing := kibana.IndexPattern{ID: "logs", Title: "logs-*", TimeFieldName: "time"}
+	mock := &mockAPI{indexPatterns: map[string]kibana.IndexPattern{"logs": existing}}
+	auditLog := &mockLog{}
+	api := NewAPI(mock, auditLog, "", log.Default())
+
+	ctx := store.WithRecorder(context.Background(), store.NewRecorder("Auto Index Pattern"))
+	ctx = WithIndices(ctx, map[string][]string{"metrics-*": {"metrics-2020", "metrics-2021"}})
+
+	overwritten := kibana.IndexPattern{ID: "logs", Title: "logs-*", TimeFieldName: "@timestamp"}
+	created := kibana.IndexPattern{Title: "metrics-*"}
+	err := api.BulkCreateIndexPattern(ctx, []kibana.IndexPattern{overwritten, created})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = api.SetDefaultIndexPattern(ctx, "logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Event{
+		{Task: "Auto Index Pattern", Space: "default", Action: ActionOverwrite, Type: "index-pattern", ID: "logs", Title: "logs-*", Before: &existing, After: &overwritten},
+		{Task: "Auto Index Pattern", Space: "default", Action: ActionCreate, Type: "index-pattern", Title: "metrics-*", After: &created, Indices: []string{"metrics-2020", "metrics-2021"}},
+		{Task: "Auto Index Pattern", Space: "default", Action: ActionCreate, Type: "config", ID: "defaultIndex", After: "logs"},
+	}
+
+	if len(auditLog.events) != len(expected) {
+		t.Fatalf("Expected %d events, got %d", len(expected), len(auditLog.events))
+	}
+
+	for i, event := range auditLog.events {
+		if event.Timestamp.IsZero() {
+			t.Errorf("Expected event %d to have a timestamp", i)
+		}
+		event.Timestamp = expected[i].Timestamp
+		if !reflect.DeepEqual(event, expected[i]) {
+			t.Errorf("Expected event %d to be %+v, got %+v", i, expected[i], event)
+		}
+	}
+}
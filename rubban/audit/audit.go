@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sherifabdlnaby/rubban/config"
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+)
+
+//Actions of an Event
+const (
+	ActionCreate    = "create"
+	ActionOverwrite = "overwrite"
+	ActionDelete    = "delete"
+	ActionImport    = "import"
+)
+
+//Event is an audit record of a single mutation Rubban made to Kibana.
+type Event struct {
+	Timestamp time.Time   `json:"@timestamp"`
+	Task      string      `json:"task,omitempty"`
+	Space     string      `json:"space"`
+	Action    string      `json:"action"`
+	Type      string      `json:"type"`
+	ID        string      `json:"id,omitempty"`
+	Title     string      `json:"title,omitempty"`
+	Before    interface{} `json:"before,omitempty"`
+	After     interface{} `json:"after,omitempty"`
+	Indices   []string    `json:"indices,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+//Log is an append-only log of audit events.
+type Log interface {
+	// Write Append an event to the log.
+	Write(ctx context.Context, event Event) error
+
+	// Close Close the log.
+	Close() error
+}
+
+//NewLog Constructor
+func NewLog(config config.Audit, api kibana.API) (Log, error) {
+	switch config.Backend {
+	case "file":
+		return newFileLog(config.File.Path)
+	case "elasticsearch":
+		return newElasticsearchLog(config.Elasticsearch.Index, api), nil
+	default:
+		return nil, fmt.Errorf("unknown audit backend [%s]", config.Backend)
+	}
+}
+
+type indicesKey struct{}
+
+//WithIndices Return a copy of ctx carrying the indices that triggered each index pattern (by title), they're added to
+//the index pattern's audit events.
+func WithIndices(ctx context.Context, indices map[string][]string) context.Context {
+	return context.WithValue(ctx, indicesKey{}, indices)
+}
+
+func indices(ctx context.Context, title string) []string {
+	indices, _ := ctx.Value(indicesKey{}).(map[string][]string)
+	return indices[title]
+}
@@ -0,0 +1,34 @@
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+)
+
+//elasticsearchLog is a Log in an Elasticsearch index (through Kibana's API), a document per event.
+//(Events are only created, never updated)
+type elasticsearchLog struct {
+	index string
+	api   kibana.API
+}
+
+func newElasticsearchLog(index string, api kibana.API) *elasticsearchLog {
+	return &elasticsearchLog{index: index, api: api}
+}
+
+func (e *elasticsearchLog) Write(ctx context.Context, event Event) error {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	id := fmt.Sprintf("%019d-%s", event.Timestamp.UnixNano(), hex.EncodeToString(suffix))
+	return e.api.IndexDocument(ctx, e.index, id, event, nil)
+}
+
+func (e *elasticsearchLog) Close() error {
+	return nil
+}
@@ -0,0 +1,42 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+//fileLog is a Log in a local file, an event per line as JSON.
+type fileLog struct {
+	file *os.File
+	mx   sync.Mutex
+}
+
+func newFileLog(path string) (*fileLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &fileLog{file: file}, nil
+}
+
+func (f *fileLog) Write(ctx context.Context, event Event) error {
+	buff, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	_, err = f.file.Write(append(buff, '\n'))
+	if err != nil {
+		return err
+	}
+	return f.file.Sync()
+}
+
+func (f *fileLog) Close() error {
+	return f.file.Close()
+}
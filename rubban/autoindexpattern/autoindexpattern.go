@@ -1,9 +1,13 @@
 package autoindexpattern
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"regexp"
+	"sort"
 	"strings"
+	"text/template"
 
 	"github.com/sherifabdlnaby/rubban/config"
 	"github.com/sherifabdlnaby/rubban/log"
@@ -13,10 +17,18 @@ import (
 
 //GeneralPattern hold attributes for a GeneralPattern loaded from config.
 type GeneralPattern struct {
-	Pattern       string
-	regex         regexp.Regexp
-	TimeFieldName string
-	matchGroups   []int
+	Pattern             string
+	raw                 string
+	regex               regexp.Regexp
+	titleRegex          *regexp.Regexp
+	TimeFieldName       string
+	TimeFieldCandidates []string
+	matchGroups         []int
+	idTemplate          *template.Template
+	nameTemplate        *template.Template
+	attributes          kibana.IndexPattern
+	dashboardTemplate   string
+	ruleTemplate        string
 }
 
 //AutoIndexPattern hold attributes for a RunAutoIndexPattern loaded from config.
@@ -24,6 +36,8 @@ type AutoIndexPattern struct {
 	name            string
 	concurrency     int
 	GeneralPatterns []GeneralPattern
+	rulesIndex      string
+	rulesID         string
 	kibana          kibana.API
 	log             log.Logger
 }
@@ -38,11 +52,21 @@ func NewAutoIndexPattern(config config.AutoIndexPattern, kibana kibana.API, log
 
 	for _, pattern := range config.GeneralPatterns {
 		regex := regexp.MustCompile(utils.PatternToRegex(pattern.Pattern))
+		// Titles of index patterns matching the general pattern (compiled once, as it's used for every index pattern)
+		titleRegex := regexp.MustCompile("^" + utils.PatternToRegex(replaceForPattern.Replace(pattern.Pattern)) + "$")
 		generalPattern = append(generalPattern, GeneralPattern{
-			Pattern:       replaceForPattern.Replace(pattern.Pattern),
-			regex:         *regex,
-			TimeFieldName: pattern.TimeFieldName,
-			matchGroups:   getMatchGroups(pattern.Pattern),
+			Pattern:             replaceForPattern.Replace(pattern.Pattern),
+			raw:                 pattern.Pattern,
+			regex:               *regex,
+			titleRegex:          titleRegex,
+			TimeFieldName:       pattern.TimeFieldName,
+			TimeFieldCandidates: pattern.TimeFieldCandidates,
+			matchGroups:         getMatchGroups(pattern.Pattern),
+			idTemplate:          newTemplate("id", pattern.IDTemplate),
+			nameTemplate:        newTemplate("name", pattern.NameTemplate),
+			attributes:          newAttributes(pattern.Attributes),
+			dashboardTemplate:   pattern.DashboardTemplate,
+			ruleTemplate:        pattern.RuleTemplate,
 		})
 	}
 
@@ -50,69 +74,223 @@ func NewAutoIndexPattern(config config.AutoIndexPattern, kibana kibana.API, log
 		name:            "Auto Index Pattern",
 		concurrency:     config.Concurrency,
 		GeneralPatterns: generalPattern,
+		rulesIndex:      config.Elasticsearch.Index,
+		rulesID:         config.Elasticsearch.ID,
 		kibana:          kibana,
 		log:             log,
 	}
 }
 
-func (a *AutoIndexPattern) getIndexPattern(ctx context.Context, generalPattern GeneralPattern) map[string]kibana.IndexPattern {
+// indexPattern is an index pattern to be created, the indices that triggered its creation, and the general pattern's
+// dashboard and rule templates with the data to render them.
+type indexPattern struct {
+	kibana.IndexPattern
+	indices           []string
+	dashboardTemplate string
+	ruleTemplate      string
+	data              map[string]string
+}
 
-	newIndexPatterns := make(map[string]kibana.IndexPattern)
+// discovery is a snapshot of indices and index patterns, fetched once per run and shared by all general patterns.
+// failed holds general patterns whose indices couldn't be fetched, and errors the failed requests.
+type discovery struct {
+	indices       []string
+	indexPatterns []kibana.IndexPattern
+	failed        map[string]bool
+	errors        []error
+}
 
-	// Get Current IndexPattern Matching Given General Patterns
-	indexPatterns, err := a.kibana.IndexPatterns(ctx, generalPattern.Pattern, nil)
-	if err != nil {
-		a.log.Warnw("failed to get index patterns matching general pattern. escaping this one...",
-			"generalPattern", generalPattern.Pattern, "error", err.Error())
-		return newIndexPatterns
+// maxFilterLength is the max length of a comma separated list of general patterns used to fetch indices in one request.
+const maxFilterLength = 2048
+
+// discover fetch indices matching any general pattern, and all index patterns. Discovery fails if listing index patterns
+// fails, as acting on a partial listing would make indices look unmatched and re-create their index patterns. If fetching
+// indices of a group of general patterns fails, they are recorded as failed (see discovery) and the other groups are kept.
+func (a *AutoIndexPattern) discover(ctx context.Context) (*discovery, error) {
+	d := &discovery{failed: make(map[string]bool)}
+
+	// Fetch indices of all general patterns in as few requests as possible, patterns of remote clusters' indices are
+	// fetched separately (they need a newer Elasticsearch, see kibana.API.Indices)
+	filters := make(map[bool][]string)
+	seen := make(map[string]bool)
+	for _, generalPattern := range a.GeneralPatterns {
+		if seen[generalPattern.Pattern] {
+			continue
+		}
+		seen[generalPattern.Pattern] = true
+
+		remote := strings.Contains(generalPattern.Pattern, ":")
+		last := len(filters[remote]) - 1
+		if last >= 0 && len(filters[remote][last])+len(generalPattern.Pattern) < maxFilterLength {
+			filters[remote][last] += "," + generalPattern.Pattern
+		} else {
+			filters[remote] = append(filters[remote], generalPattern.Pattern)
+		}
 	}
 
-	patternsList := make([]string, 0)
-	for _, index := range indexPatterns {
-		patternsList = append(patternsList, utils.PatternToRegex(index.Title))
+	indexSet := make(map[string]bool)
+	for _, filter := range append(filters[false], filters[true]...) {
+		indices, err := a.kibana.Indices(ctx, filter)
+		if err != nil {
+			for _, pattern := range strings.Split(filter, ",") {
+				d.failed[pattern] = true
+			}
+			d.errors = append(d.errors, fmt.Errorf("failed to get indices matching general patterns [%s], error: %s", filter, err.Error()))
+			continue
+		}
+		for _, index := range indices {
+			if !indexSet[index.Name] {
+				indexSet[index.Name] = true
+				d.indices = append(d.indices, index.Name)
+			}
+		}
 	}
 
-	// Get Indices Matching Given General Pattern
-	indices, err := a.kibana.Indices(ctx, generalPattern.Pattern)
+	indexPatterns, err := a.kibana.IndexPatterns(ctx, "*", nil)
 	if err != nil {
-		a.log.Warnw("failed to get indices matching a general pattern. escaping this one...",
-			"generalPattern", generalPattern.Pattern, "error", err.Error())
-		return newIndexPatterns
+		return nil, fmt.Errorf("failed to get index patterns, error: %s", err.Error())
 	}
+	d.indexPatterns = indexPatterns
 
-	// Get Indices That Hasn't Matched ANY IndexPattern
+	return d, nil
+}
+
+// getIndexPattern return index patterns to create for discovered indices matching general pattern that no index pattern
+// (matching general pattern) matches. (index patterns that fail to render are skipped)
+func (a *AutoIndexPattern) getIndexPattern(ctx context.Context, generalPattern GeneralPattern, d *discovery) map[string]indexPattern {
 
-	//// Build regex
-	var matchedIndicesRegx *regexp.Regexp
+	newIndexPatterns := make(map[string]indexPattern)
 
-	if len(patternsList) > 0 {
-		matchedIndicesRegx = regexp.MustCompile(strings.Join(patternsList, "|"))
-	} else {
-		// If no PatternList that means that the first time to encounter this pattern. So we won't match anything.
-		matchedIndicesRegx = regexp.MustCompile("$.")
+	// Get Current IndexPattern Matching Given General Patterns
+	matchedIndexPatterns := utils.NewGlobSet()
+	for _, index := range d.indexPatterns {
+		if generalPattern.titleRegex.MatchString(index.Title) {
+			matchedIndexPatterns.Add(index.Title)
+		}
 	}
 
+	// Get Indices That Hasn't Matched ANY IndexPattern
 	unmatchedIndices := make([]string, 0)
-	for _, index := range indices {
-		if !matchedIndicesRegx.MatchString(index.Name) {
-			unmatchedIndices = append(unmatchedIndices, index.Name)
+	for _, index := range d.indices {
+		if utils.MatchGlob(generalPattern.Pattern, index) && !matchedIndexPatterns.Match(index) {
+			unmatchedIndices = append(unmatchedIndices, index)
 		}
 	}
 
 	// Build Index Pattern for every unmatched Index
 	for _, unmatchedIndex := range unmatchedIndices {
-		newIndexPattern := buildIndexPattern(generalPattern, unmatchedIndex)
-		newIndexPatterns[newIndexPattern] = kibana.IndexPattern{
-			Title:         newIndexPattern,
-			TimeFieldName: generalPattern.TimeFieldName,
+		newIndexPattern, groups := buildIndexPattern(generalPattern, unmatchedIndex)
+		if pattern, ok := newIndexPatterns[newIndexPattern]; ok {
+			pattern.indices = append(pattern.indices, unmatchedIndex)
+			newIndexPatterns[newIndexPattern] = pattern
+			continue
+		}
+
+		data := templateData(generalPattern, newIndexPattern, groups)
+		id, name, err := renderTemplates(generalPattern, data)
+		if err != nil {
+			a.log.Warnw("failed to render id or name template for index pattern. escaping this one...",
+				"generalPattern", generalPattern.Pattern, "indexPattern", newIndexPattern, "error", err.Error())
+			continue
+		}
+
+		timeFieldName, err := a.timeFieldName(ctx, generalPattern, newIndexPattern)
+		if err != nil {
+			a.log.Warnw("failed to detect time field for index pattern. escaping this one...",
+				"generalPattern", generalPattern.Pattern, "indexPattern", newIndexPattern, "error", err.Error())
+			continue
 		}
+
+		pattern := generalPattern.attributes
+		pattern.ID = id
+		pattern.Title = newIndexPattern
+		pattern.Name = name
+		pattern.TimeFieldName = timeFieldName
+		newIndexPatterns[newIndexPattern] = indexPattern{IndexPattern: pattern, indices: []string{unmatchedIndex},
+			dashboardTemplate: generalPattern.dashboardTemplate, ruleTemplate: generalPattern.ruleTemplate, data: data}
 	}
 
 	return newIndexPatterns
 }
 
-func buildIndexPattern(generalPattern GeneralPattern, unmatchedIndex string) string {
+// provisioned return index patterns created from general patterns, new ones and existing ones (current) with the title
+// and ID a general pattern would create them with, sorted by title. Their dashboard and rule templates are provisioned
+// every run, so ones that failed are retried and templates added later are applied. (index patterns created by users
+// have other IDs, and are left alone)
+func (a *AutoIndexPattern) provisioned(current []kibana.IndexPattern, created map[string]indexPattern) []indexPattern {
+	patterns := make(map[string]indexPattern, len(created))
+	for title, pattern := range created {
+		patterns[title] = pattern
+	}
+
+	for _, generalPattern := range a.GeneralPatterns {
+		if generalPattern.idTemplate == nil || (generalPattern.dashboardTemplate == "" && generalPattern.ruleTemplate == "") {
+			continue
+		}
+
+		for _, existing := range current {
+			if _, ok := patterns[existing.Title]; ok || !generalPattern.titleRegex.MatchString(existing.Title) {
+				continue
+			}
+
+			title, groups := buildIndexPattern(generalPattern, existing.Title)
+			if title != existing.Title {
+				continue
+			}
+
+			data := templateData(generalPattern, title, groups)
+			id, err := executeTemplate(generalPattern.idTemplate, data)
+			if err != nil || id != existing.ID {
+				continue
+			}
+
+			patterns[title] = indexPattern{IndexPattern: existing, dashboardTemplate: generalPattern.dashboardTemplate,
+				ruleTemplate: generalPattern.ruleTemplate, data: data}
+		}
+	}
+
+	provisioned := make([]indexPattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		provisioned = append(provisioned, pattern)
+	}
+	sort.Slice(provisioned, func(i, j int) bool { return provisioned[i].Title < provisioned[j].Title })
+	return provisioned
+}
+
+// timeFieldName return the time field of a new index pattern, if the general pattern has time field candidates it will
+// pick the first candidate that is a date field in the index pattern's indices, or none if no candidate exists.
+func (a *AutoIndexPattern) timeFieldName(ctx context.Context, generalPattern GeneralPattern, indexPattern string) (string, error) {
+	if len(generalPattern.TimeFieldCandidates) == 0 {
+		return generalPattern.TimeFieldName, nil
+	}
+
+	fields, err := a.kibana.Fields(ctx, indexPattern)
+	if err != nil {
+		return "", err
+	}
+
+	dateFields := make(map[string]bool)
+	for _, field := range fields {
+		if field.Type == "date" {
+			dateFields[field.Name] = true
+		}
+	}
+
+	for _, candidate := range generalPattern.TimeFieldCandidates {
+		if dateFields[candidate] {
+			return candidate, nil
+		}
+	}
+
+	a.log.Debugw("none of the time field candidates exists, index pattern will not be time based.",
+		"indexPattern", indexPattern, "candidates", generalPattern.TimeFieldCandidates)
+	return "", nil
+}
+
+// buildIndexPattern return the index pattern covering the given index, and the values matched by each '?' group.
+func buildIndexPattern(generalPattern GeneralPattern, unmatchedIndex string) (string, []string) {
 	matchGroups := generalPattern.regex.FindStringSubmatch(unmatchedIndex)
+	groups := make([]string, 0, len(generalPattern.matchGroups))
 	newIndexPattern := generalPattern.Pattern
 	/// Start from 1 to escape first match group which is the whole string.
 	for i := 1; i < len(matchGroups); i++ {
@@ -126,6 +304,7 @@ func buildIndexPattern(generalPattern GeneralPattern, unmatchedIndex string) str
 		if match {
 			// This is a match Group
 			newIndexPattern = strings.Replace(newIndexPattern, "*", matchGroups[i], 1)
+			groups = append(groups, matchGroups[i])
 		} else {
 			// This is a wildcard (make it ? for now) (yes there can be a more efficient logic for that.)
 			newIndexPattern = strings.Replace(newIndexPattern, "*", "?", 1)
@@ -133,7 +312,59 @@ func buildIndexPattern(generalPattern GeneralPattern, unmatchedIndex string) str
 
 	}
 	newIndexPattern = strings.Replace(newIndexPattern, "?", "*", -1)
-	return newIndexPattern
+	return newIndexPattern, groups
+}
+
+// templateData return the data templates of a generated index pattern are rendered with, they can reference {{.title}},
+// {{.generalPattern}}, and {{.group1}}...{{.groupN}} for every '?' match group.
+func templateData(generalPattern GeneralPattern, title string, groups []string) map[string]string {
+	data := map[string]string{
+		"title":          title,
+		"generalPattern": generalPattern.Pattern,
+	}
+	for i, group := range groups {
+		data[fmt.Sprintf("group%d", i+1)] = group
+	}
+	return data
+}
+
+// renderTemplates render the general pattern's id and name templates for a generated index pattern.
+func renderTemplates(generalPattern GeneralPattern, data map[string]string) (string, string, error) {
+	id, err := executeTemplate(generalPattern.idTemplate, data)
+	if err != nil {
+		return "", "", err
+	}
+
+	name, err := executeTemplate(generalPattern.nameTemplate, data)
+	if err != nil {
+		return "", "", err
+	}
+
+	return id, name, nil
+}
+
+func executeTemplate(tmpl *template.Template, data map[string]string) (string, error) {
+	if tmpl == nil {
+		return "", nil
+	}
+	buff := bytes.Buffer{}
+	if err := tmpl.Execute(&buff, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buff.String()), nil
+}
+
+// newAttributes build the extra attributes applied to every index pattern created from a general pattern.
+func newAttributes(attributes config.IndexPatternAttributes) kibana.IndexPattern {
+	return kibana.NewIndexPatternAttributes(attributes)
+}
+
+// newTemplate parse an optional template (templates are validated when loading config).
+func newTemplate(name, text string) *template.Template {
+	if text == "" {
+		return nil
+	}
+	return template.Must(template.New(name).Option("missingkey=error").Parse(text))
 }
 
 func getMatchGroups(pattern string) []int {
@@ -2,34 +2,168 @@ package autoindexpattern
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/sherifabdlnaby/rubban/config"
 	"github.com/sherifabdlnaby/rubban/log"
 	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+	"github.com/sherifabdlnaby/rubban/rubban/store"
 )
 
 type mockAPI struct {
 	indices       []kibana.Index
 	indexPatterns []kibana.IndexPattern
+	fields        []kibana.Field
+	documents     map[string][]byte
+	rules         map[string]json.RawMessage
+	savedObjects  map[string]bool
+	listErr       error
+	indicesErrs   map[string]error
 }
 
 func (m *mockAPI) Info(ctx context.Context) (kibana.Info, error) {
 	panic("implement me")
 }
 
+func (m *mockAPI) ClusterStateVersion(ctx context.Context) (int64, error) {
+	panic("implement me")
+}
+
 func (m *mockAPI) Indices(ctx context.Context, filter string) ([]kibana.Index, error) {
-	return m.indices, nil
+	if err, ok := m.indicesErrs[filter]; ok {
+		return nil, err
+	}
+	return m.indices, m.listErr
+}
+
+func (m *mockAPI) Fields(ctx context.Context, pattern string) ([]kibana.Field, error) {
+	return m.fields, nil
 }
 
 func (m *mockAPI) IndexPatterns(ctx context.Context, filter string, fields []string) ([]kibana.IndexPattern, error) {
+	if m.listErr != nil {
+		return nil, m.listErr
+	}
 	return m.indexPatterns, nil
 }
 
+func (m *mockAPI) GetIndexPattern(ctx context.Context, id string) (kibana.IndexPattern, bool, error) {
+	if m.listErr != nil {
+		return kibana.IndexPattern{}, false, m.listErr
+	}
+	for _, indexPattern := range m.indexPatterns {
+		if indexPattern.ID == id {
+			return indexPattern, true, nil
+		}
+	}
+	return kibana.IndexPattern{}, false, nil
+}
+
 func (m *mockAPI) BulkCreateIndexPattern(ctx context.Context, indexPatterns []kibana.IndexPattern) error {
+	// Index patterns with an existing ID are overwritten
+	for _, indexPattern := range indexPatterns {
+		overwritten := false
+		for i := range m.indexPatterns {
+			if m.indexPatterns[i].ID == indexPattern.ID {
+				m.indexPatterns[i] = indexPattern
+				overwritten = true
+			}
+		}
+		if !overwritten {
+			m.indexPatterns = append(m.indexPatterns, indexPattern)
+		}
+	}
+	return nil
+}
+
+func (m *mockAPI) DeleteIndexPattern(ctx context.Context, id string) error {
+	panic("implement me")
+}
+
+func (m *mockAPI) FindSavedObjects(ctx context.Context, query kibana.SavedObjectsQuery) ([]kibana.SavedObject, error) {
 	panic("implement me")
 }
 
+func (m *mockAPI) GetSavedObject(ctx context.Context, objectType, id string) (kibana.SavedObject, bool, error) {
+	panic("implement me")
+}
+
+func (m *mockAPI) UpdateSavedObject(ctx context.Context, object kibana.SavedObject) error {
+	panic("implement me")
+}
+
+func (m *mockAPI) ExportSavedObjects(ctx context.Context, types []string) ([]byte, error) {
+	panic("implement me")
+}
+
+func (m *mockAPI) ImportSavedObjects(ctx context.Context, ndjson []byte, overwrite bool) (kibana.ImportResult, error) {
+	objects, err := kibana.DecodeSavedObjects(ndjson)
+	if err != nil {
+		return kibana.ImportResult{}, err
+	}
+	result := kibana.ImportResult{Success: true}
+	for _, object := range objects {
+		if m.savedObjects[object.Type+"/"+object.ID] && !overwrite {
+			result.Success = false
+			importError := kibana.ImportError{Type: object.Type, ID: object.ID}
+			importError.Error.Type = "conflict"
+			result.Errors = append(result.Errors, importError)
+			continue
+		}
+		m.savedObjects[object.Type+"/"+object.ID] = true
+		result.SuccessCount++
+	}
+	return result, nil
+}
+
+func (m *mockAPI) DefaultIndexPattern(ctx context.Context) (string, error) {
+	panic("implement me")
+}
+
+func (m *mockAPI) SetDefaultIndexPattern(ctx context.Context, id string) error {
+	panic("implement me")
+}
+
+func (m *mockAPI) GetDocument(ctx context.Context, index, id string, doc interface{}) (*kibana.DocumentVersion, error) {
+	raw, ok := m.documents[index+"/"+id]
+	if !ok {
+		return nil, nil
+	}
+	return &kibana.DocumentVersion{}, json.Unmarshal(raw, doc)
+}
+
+func (m *mockAPI) IndexDocument(ctx context.Context, index, id string, doc interface{}, version *kibana.DocumentVersion) error {
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	m.documents[index+"/"+id] = raw
+	return nil
+}
+
+func (m *mockAPI) SearchDocuments(ctx context.Context, index string, query interface{}) ([]json.RawMessage, error) {
+	panic("implement me")
+}
+
+func (m *mockAPI) CreateRule(ctx context.Context, id string, rule json.RawMessage) (bool, error) {
+	if _, ok := m.rules[id]; ok {
+		return false, nil
+	}
+	m.rules[id] = rule
+	return true, nil
+}
+
+func (m *mockAPI) DeleteRule(ctx context.Context, id string) error {
+	delete(m.rules, id)
+	return nil
+}
+
 func newMockAPI(indices []kibana.Index, indexPatterns []kibana.IndexPattern) kibana.API {
 	return &mockAPI{indices: indices, indexPatterns: indexPatterns}
 }
@@ -68,7 +202,7 @@ func TestAutoindexPatternMatchers(t *testing.T) {
 			generalPattern:        "?-*",
 			indices:               []kibana.Index{{Name: "-cool-index-"}, {Name: ".kibana"}, {Name: "test----aabcc2020.02.14"}},
 			indexpatterns:         []kibana.IndexPattern{},
-			expectedIndexPatterns: []string{"test-*", "-*", "*-*"},
+			expectedIndexPatterns: []string{"test-*", "-*"},
 			tcaseName:             `random gibberish that should not match most of the time besides two`,
 		},
 		{
@@ -92,6 +226,34 @@ func TestAutoindexPatternMatchers(t *testing.T) {
 			expectedIndexPatterns: []string{"foo-baz-*", "foo-bar-*"},
 			tcaseName:             `multiple matcher and matching eagerly vs. lazily test`,
 		},
+		{
+			generalPattern:        "cluster_a:logs-?-*",
+			indices:               []kibana.Index{{Name: "cluster_a:logs-api-2020.02.14"}, {Name: "cluster_a:logs-web-2020.02.14"}},
+			indexpatterns:         []kibana.IndexPattern{{Title: "cluster_a:logs-web-*", TimeFieldName: "@timestamp"}, {Title: "logs-api-*"}},
+			expectedIndexPatterns: []string{"cluster_a:logs-api-*"},
+			tcaseName:             `remote cluster test`,
+		},
+		{
+			generalPattern:        "?:logs-?-*",
+			indices:               []kibana.Index{{Name: "cluster_a:logs-api-2020.02.14"}, {Name: "cluster_b:logs-api-2020.02.14"}},
+			indexpatterns:         []kibana.IndexPattern{{Title: "*:logs-api-*", TimeFieldName: "@timestamp"}},
+			expectedIndexPatterns: []string{},
+			tcaseName:             `remote clusters already covered by a pattern of all clusters test`,
+		},
+		{
+			generalPattern:        "?:logs-?-*",
+			indices:               []kibana.Index{{Name: "cluster_a:logs-api-2020.02.14"}, {Name: "cluster_b:logs-api-2020.02.14"}},
+			indexpatterns:         []kibana.IndexPattern{},
+			expectedIndexPatterns: []string{"cluster_a:logs-api-*", "cluster_b:logs-api-*"},
+			tcaseName:             `pattern per remote cluster test`,
+		},
+		{
+			generalPattern:        "logs-?-*",
+			indices:               []kibana.Index{{Name: "logs-api-2020.02.14"}, {Name: "logs-web-2020.02.14"}},
+			indexpatterns:         []kibana.IndexPattern{{Title: "*logs-api-*"}, {Title: "logs-web-*"}},
+			expectedIndexPatterns: []string{"logs-api-*"},
+			tcaseName:             `index patterns only partially matching general pattern test`,
+		},
 	} {
 		autoIdxPttrn := NewAutoIndexPattern(config.AutoIndexPattern{
 			Enabled: true,
@@ -103,7 +265,8 @@ func TestAutoindexPatternMatchers(t *testing.T) {
 		}, newMockAPI(tcase.indices, tcase.indexpatterns), log.Default())
 
 		///
-		result := autoIdxPttrn.getIndexPattern(context.Background(), autoIdxPttrn.GeneralPatterns[0])
+		d, _ := autoIdxPttrn.discover(context.Background())
+		result := autoIdxPttrn.getIndexPattern(context.Background(), autoIdxPttrn.GeneralPatterns[0], d)
 
 		t.Run(tcase.tcaseName, func(t *testing.T) {
 			if len(tcase.expectedIndexPatterns) == 0 && len(result) != 0 {
@@ -123,3 +286,411 @@ func TestAutoindexPatternMatchers(t *testing.T) {
 
 	}
 }
+
+// TestAutoindexPatternDiscoveryFailure tests that a run is aborted when listing index patterns fails, as every index
+// would look unmatched otherwise. (creating index patterns would panic)
+func TestAutoindexPatternDiscoveryFailure(t *testing.T) {
+	autoIdxPttrn := NewAutoIndexPattern(config.AutoIndexPattern{
+		Enabled: true,
+		GeneralPatterns: []config.GeneralPattern{{
+			Pattern:       "logs-?-*",
+			TimeFieldName: "@timestamp",
+		}},
+		Schedule: "* * * * *",
+	}, &mockAPI{indices: []kibana.Index{{Name: "logs-api-2020.02.14"}}, listErr: fmt.Errorf("500 Internal Server Error")}, log.Default())
+
+	result := autoIdxPttrn.Run(context.Background())
+	if !result.Failed() {
+		t.Fatalf("expected run to fail, got %v", result)
+	}
+}
+
+// TestAutoindexPatternPartialDiscovery tests that general patterns whose indices were fetched are still matched when
+// fetching indices of others fails.
+func TestAutoindexPatternPartialDiscovery(t *testing.T) {
+	api := &mockAPI{
+		indices:     []kibana.Index{{Name: "logs-api-2020.02.14"}, {Name: "cluster_a:metrics-api-2020.02.14"}},
+		indicesErrs: map[string]error{"*:metrics-*-*": fmt.Errorf("500 Internal Server Error")},
+	}
+	autoIdxPttrn := NewAutoIndexPattern(config.AutoIndexPattern{
+		Enabled: true,
+		GeneralPatterns: []config.GeneralPattern{
+			{Pattern: "logs-?-*", TimeFieldName: "@timestamp"},
+			{Pattern: "?:metrics-?-*", TimeFieldName: "@timestamp"},
+		},
+		Schedule: "* * * * *",
+	}, api, log.Default())
+
+	result := autoIdxPttrn.Run(context.Background())
+	if !result.Failed() {
+		t.Fatalf("expected run to record the failed general pattern, got %v", result)
+	}
+	if len(api.indexPatterns) != 1 || api.indexPatterns[0].Title != "logs-api-*" {
+		t.Errorf("expected only index pattern logs-api-* to be created, got %v", api.indexPatterns)
+	}
+
+	if _, err := autoIdxPttrn.Missing(context.Background()); err == nil {
+		t.Errorf("expected missing index patterns to fail on a partial discovery")
+	}
+}
+
+// TestAutoindexPatternTemplates tests rendering of id and name templates.
+func TestAutoindexPatternTemplates(t *testing.T) {
+	autoIdxPttrn := NewAutoIndexPattern(config.AutoIndexPattern{
+		Enabled: true,
+		GeneralPatterns: []config.GeneralPattern{{
+			Pattern:       "logs-?-?-*",
+			TimeFieldName: "@timestamp",
+			IDTemplate:    "auto-{{.group1}}-{{.group2}}",
+			NameTemplate:  "{{.group2}} ({{.title}})",
+		}},
+		Schedule: "* * * * *",
+	}, newMockAPI([]kibana.Index{{Name: "logs-api-prod-2020.02.14"}, {Name: "logs-api-prod-2020.02.15"}}, nil), log.Default())
+
+	d, _ := autoIdxPttrn.discover(context.Background())
+	result := autoIdxPttrn.getIndexPattern(context.Background(), autoIdxPttrn.GeneralPatterns[0], d)
+
+	pattern, ok := result["logs-api-prod-*"]
+	if !ok || len(result) != 1 {
+		t.Fatalf("expected only index pattern logs-api-prod-* (%v)", result)
+	}
+	if pattern.ID != "auto-api-prod" {
+		t.Fatalf("expected id auto-api-prod but got %s", pattern.ID)
+	}
+	if pattern.Name != "prod (logs-api-prod-*)" {
+		t.Fatalf("expected name \"prod (logs-api-prod-*)\" but got %s", pattern.Name)
+	}
+}
+
+// TestAutoindexPatternIDConflicts tests that index patterns rendering an ID already used by another title are escaped.
+func TestAutoindexPatternIDConflicts(t *testing.T) {
+	api := &mockAPI{
+		indices: []kibana.Index{{Name: "logs-api-prod-2020.02.14"}, {Name: "logs-api-dev-2020.02.14"},
+			{Name: "logs-web-prod-2020.02.14"}, {Name: "logs-db-prod-2020.02.14"}},
+		indexPatterns: []kibana.IndexPattern{{ID: "auto-web", Title: "logs-web-old-*"}},
+	}
+	autoIdxPttrn := NewAutoIndexPattern(config.AutoIndexPattern{
+		Enabled: true,
+		GeneralPatterns: []config.GeneralPattern{{
+			Pattern:       "logs-?-?-*",
+			TimeFieldName: "@timestamp",
+			IDTemplate:    "auto-{{.group1}}",
+		}},
+		Concurrency: 1,
+		Schedule:    "* * * * *",
+	}, api, log.Default())
+
+	// Run repeatedly, titles conflicting with existing index patterns must never overwrite them.
+	for i := 0; i < 3; i++ {
+		result := autoIdxPttrn.Run(context.Background())
+		if result.Failed() {
+			t.Fatalf("unexpected errors %v", result)
+		}
+	}
+
+	titles := make(map[string]string)
+	for _, indexPattern := range api.indexPatterns {
+		titles[indexPattern.ID] = indexPattern.Title
+	}
+	expected := map[string]string{"auto-web": "logs-web-old-*", "auto-api": "logs-api-dev-*", "auto-db": "logs-db-prod-*"}
+	if !reflect.DeepEqual(titles, expected) {
+		t.Errorf("expected index patterns %v, got %v", expected, titles)
+	}
+}
+
+// TestAutoindexPatternTimeFieldCandidates tests time field detection.
+func TestAutoindexPatternTimeFieldCandidates(t *testing.T) {
+	for _, tcase := range []struct {
+		fields                []kibana.Field
+		expectedTimeFieldName string
+		tcaseName             string
+	}{
+		{
+			fields:                []kibana.Field{{Name: "timestamp", Type: "date"}, {Name: "event.created", Type: "date"}},
+			expectedTimeFieldName: "event.created",
+			tcaseName:             `picks candidates by priority`,
+		},
+		{
+			fields:                []kibana.Field{{Name: "@timestamp", Type: "keyword"}, {Name: "timestamp", Type: "date"}},
+			expectedTimeFieldName: "timestamp",
+			tcaseName:             `ignores non date fields`,
+		},
+		{
+			fields:                []kibana.Field{{Name: "message", Type: "string"}},
+			expectedTimeFieldName: "",
+			tcaseName:             `falls back to none`,
+		},
+	} {
+		autoIdxPttrn := NewAutoIndexPattern(config.AutoIndexPattern{
+			Enabled: true,
+			GeneralPatterns: []config.GeneralPattern{{
+				Pattern:             "?-*",
+				TimeFieldCandidates: []string{"@timestamp", "event.created", "timestamp"},
+			}},
+			Schedule: "* * * * *",
+		}, &mockAPI{indices: []kibana.Index{{Name: "foo-2020.02.14"}}, fields: tcase.fields}, log.Default())
+
+		d, _ := autoIdxPttrn.discover(context.Background())
+		result := autoIdxPttrn.getIndexPattern(context.Background(), autoIdxPttrn.GeneralPatterns[0], d)
+
+		t.Run(tcase.tcaseName, func(t *testing.T) {
+			pattern, ok := result["foo-*"]
+			if !ok {
+				t.Fatalf("failed to find index pattern foo-* (%v)", result)
+			}
+			if pattern.TimeFieldName != tcase.expectedTimeFieldName {
+				t.Fatalf("expected time field %q but got %q", tcase.expectedTimeFieldName, pattern.TimeFieldName)
+			}
+		})
+	}
+}
+
+// TestAutoindexPatternAttributes tests extra attributes are applied to created index patterns.
+func TestAutoindexPatternAttributes(t *testing.T) {
+	autoIdxPttrn := NewAutoIndexPattern(config.AutoIndexPattern{
+		Enabled: true,
+		GeneralPatterns: []config.GeneralPattern{{
+			Pattern:       "?-*",
+			TimeFieldName: "@timestamp",
+			Attributes: config.IndexPatternAttributes{
+				FieldFormatMap: map[string]interface{}{"bytes": map[string]interface{}{"id": "bytes"}},
+				SourceFilters:  []string{"secret*"},
+				AllowNoIndex:   true,
+			},
+		}},
+		Schedule: "* * * * *",
+	}, newMockAPI([]kibana.Index{{Name: "foo-2020.02.14"}}, nil), log.Default())
+
+	d, _ := autoIdxPttrn.discover(context.Background())
+	result := autoIdxPttrn.getIndexPattern(context.Background(), autoIdxPttrn.GeneralPatterns[0], d)
+
+	pattern := result["foo-*"]
+	if pattern.Title != "foo-*" || pattern.TimeFieldName != "@timestamp" {
+		t.Fatalf("unexpected index pattern %v", pattern)
+	}
+	if pattern.FieldFormatMap != `{"bytes":{"id":"bytes"}}` {
+		t.Fatalf("unexpected fieldFormatMap %s", pattern.FieldFormatMap)
+	}
+	if pattern.SourceFilters != `[{"value":"secret*"}]` {
+		t.Fatalf("unexpected sourceFilters %s", pattern.SourceFilters)
+	}
+	if pattern.RuntimeFieldMap != "" || !pattern.AllowNoIndex {
+		t.Fatalf("unexpected runtimeFieldMap or allowNoIndex %v", pattern)
+	}
+}
+
+// BenchmarkAutoindexPatternMatchers benchmarks matching 50k indices against 10k existing index patterns.
+func BenchmarkAutoindexPatternMatchers(b *testing.B) {
+	indices := make([]kibana.Index, 0, 50000)
+	for i := 0; i < 50000; i++ {
+		indices = append(indices, kibana.Index{Name: fmt.Sprintf("logs-service%d-2020.02.%02d", i%20000, i/20000+1)})
+	}
+	indexPatterns := make([]kibana.IndexPattern, 0, 10000)
+	for i := 0; i < 10000; i++ {
+		indexPatterns = append(indexPatterns, kibana.IndexPattern{Title: fmt.Sprintf("logs-service%d-*", i*2)})
+	}
+
+	autoIdxPttrn := NewAutoIndexPattern(config.AutoIndexPattern{
+		Enabled: true,
+		GeneralPatterns: []config.GeneralPattern{{
+			Pattern:       "logs-?-*",
+			TimeFieldName: "@timestamp",
+		}},
+		Schedule: "* * * * *",
+	}, newMockAPI(indices, indexPatterns), log.Default())
+
+	d, _ := autoIdxPttrn.discover(context.Background())
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		result := autoIdxPttrn.getIndexPattern(context.Background(), autoIdxPttrn.GeneralPatterns[0], d)
+		if len(result) != 10000 {
+			b.Fatalf("expected 10000 index patterns but got %d", len(result))
+		}
+	}
+}
+
+// TestInstantiateTemplate tests rewiring a dashboard template to a generated index pattern.
+func TestInstantiateTemplate(t *testing.T) {
+	objects, err := kibana.DecodeSavedObjects([]byte(`{"type":"index-pattern","id":"template","attributes":{"title":"logs-template-*"},"references":[]}
+{"type":"visualization","id":"errors","attributes":{"title":"Errors of {{.group1}}","visState":"{\"title\":\"{{value}}\"}"},"references":[{"type":"index-pattern","id":"template","name":"kibanaSavedObjectMeta.searchSourceJSON.index"}]}
+{"type":"dashboard","id":"overview","attributes":{"title":"{{.group1}} Overview","description":"Indices {{.title}}"},"references":[{"type":"visualization","id":"errors","name":"panel_0"},{"type":"visualization","id":"shared","name":"panel_1"}]}
+{"exportedCount":3,"missingRefCount":0,"missingReferences":[]}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instances, err := instantiateTemplate(objects, "auto-checkout", map[string]string{"title": "logs-checkout-*", "group1": "checkout"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(instances) != 2 {
+		t.Fatalf("expected index patterns of the template to be skipped, got %d saved objects", len(instances))
+	}
+
+	visualization, dashboard := instances[0], instances[1]
+	if visualization.ID != "auto-checkout-errors" || visualization.Title() != "Errors of checkout" ||
+		visualization.References[0].ID != "auto-checkout" {
+		t.Errorf("unexpected visualization %s %s %v", visualization.ID, visualization.Title(), visualization.References)
+	}
+	if !strings.Contains(string(visualization.Attributes), `{{value}}`) {
+		t.Errorf("expected attributes other than title and description to be kept as is, got %s", visualization.Attributes)
+	}
+
+	if dashboard.ID != "auto-checkout-overview" || dashboard.Title() != "checkout Overview" ||
+		dashboard.References[0].ID != "auto-checkout-errors" || dashboard.References[1].ID != "shared" {
+		t.Errorf("unexpected dashboard %s %s %v", dashboard.ID, dashboard.Title(), dashboard.References)
+	}
+	if !strings.Contains(string(dashboard.Attributes), `"description":"Indices logs-checkout-*"`) {
+		t.Errorf("expected description to be rendered, got %s", dashboard.Attributes)
+	}
+}
+
+// TestInstantiateDashboards tests instantiating dashboard templates of new and existing index patterns once.
+func TestInstantiateDashboards(t *testing.T) {
+	file, err := ioutil.TempFile("", "dashboard-*.ndjson")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	_, _ = file.WriteString(`{"type":"dashboard","id":"overview","attributes":{"title":"{{.group1}} Overview"},"references":[]}` + "\n")
+	_ = file.Close()
+
+	// auto-web's dashboard failed to be imported before, and user-legacy was created by a user.
+	api := &mockAPI{
+		indices: []kibana.Index{{Name: "logs-web-2020.02.14"}, {Name: "logs-api-2020.02.14"}, {Name: "logs-legacy-2020.02.14"}},
+		indexPatterns: []kibana.IndexPattern{{ID: "auto-web", Title: "logs-web-*"},
+			{ID: "user-legacy", Title: "logs-legacy-*"}},
+		savedObjects: map[string]bool{},
+	}
+	autoIdxPttrn := NewAutoIndexPattern(config.AutoIndexPattern{
+		Enabled: true,
+		GeneralPatterns: []config.GeneralPattern{{
+			Pattern:           "logs-?-*",
+			IDTemplate:        "auto-{{.group1}}",
+			DashboardTemplate: file.Name(),
+		}},
+		Concurrency: 1,
+		Schedule:    "* * * * *",
+	}, api, log.Default())
+
+	result := autoIdxPttrn.Run(context.Background())
+	if result.Failed() || result.Counts["templated"] != 2 {
+		t.Fatalf("expected dashboards of new and existing index patterns to be instantiated, got %+v", result)
+	}
+	expected := map[string]bool{"dashboard/auto-api-overview": true, "dashboard/auto-web-overview": true}
+	if !reflect.DeepEqual(api.savedObjects, expected) {
+		t.Errorf("expected saved objects %v, got %v", expected, api.savedObjects)
+	}
+
+	result = autoIdxPttrn.Run(context.Background())
+	if result.Failed() || result.Counts["templated"] != 0 {
+		t.Errorf("expected instantiated dashboards to be kept as is, got %+v", result)
+	}
+}
+
+// TestProvisionRules tests creating rules of new and existing index patterns, and deleting owned rules of retired ones.
+func TestProvisionRules(t *testing.T) {
+	file, err := ioutil.TempFile("", "rule-*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	_, _ = file.WriteString(`{"name":"No data in {{.group1}}","params":{"index":["{{.title}}"]}}`)
+	_ = file.Close()
+
+	api := &mockAPI{
+		documents: map[string][]byte{".rubban/rules": []byte(`{"rules":{"auto-old-rule":"auto-old","auto-db-rule":"auto-db"}}`)},
+		rules: map[string]json.RawMessage{
+			"auto-old-rule":     json.RawMessage(`{}`),
+			"auto-db-rule":      json.RawMessage(`{}`),
+			"auto-cache-rule":   json.RawMessage(`{}`),
+			"user-defined-rule": json.RawMessage(`{}`),
+		},
+		// auto-db was created after the listing of index patterns
+		indexPatterns: []kibana.IndexPattern{{ID: "auto-web", Title: "logs-web-*"}, {ID: "auto-db", Title: "logs-db-*"}},
+	}
+	autoIdxPttrn := NewAutoIndexPattern(config.AutoIndexPattern{
+		Enabled: true,
+		GeneralPatterns: []config.GeneralPattern{{
+			Pattern:      "logs-?-*",
+			IDTemplate:   "auto-{{.group1}}",
+			RuleTemplate: file.Name(),
+		}},
+		Elasticsearch: config.AutoIndexPatternElasticsearch{Index: ".rubban", ID: "rules"},
+		Schedule:      "* * * * *",
+	}, api, log.Default())
+
+	created := map[string]indexPattern{"logs-api-*": {
+		IndexPattern: kibana.IndexPattern{ID: "auto-api", Title: "logs-api-*"},
+		ruleTemplate: file.Name(),
+		data:         map[string]string{"title": "logs-api-*", "group1": "api"},
+	}}
+	// auto-web's rule failed to be created before, auto-cache's rule exists but isn't owned, and user-legacy was
+	// created by a user.
+	current := []kibana.IndexPattern{{ID: "auto-web", Title: "logs-web-*"}, {ID: "auto-cache", Title: "logs-cache-*"},
+		{ID: "user-legacy", Title: "logs-legacy-*"}}
+
+	result := store.NewResult()
+	autoIdxPttrn.provisionRules(context.Background(), autoIdxPttrn.provisioned(current, created), current, &result)
+
+	if result.Failed() {
+		t.Fatalf("unexpected errors %v", result)
+	}
+	if rule, ok := api.rules["auto-api-rule"]; !ok || string(rule) != `{"name":"No data in api","params":{"index":["logs-api-*"]}}` {
+		t.Errorf("expected rule of new index pattern to be created, got %s", rule)
+	}
+	if rule, ok := api.rules["auto-web-rule"]; !ok || string(rule) != `{"name":"No data in web","params":{"index":["logs-web-*"]}}` {
+		t.Errorf("expected missing rule of existing index pattern to be created, got %s", rule)
+	}
+	if _, ok := api.rules["user-legacy-rule"]; ok {
+		t.Errorf("expected no rule for index patterns created by users")
+	}
+	if _, ok := api.rules["auto-old-rule"]; ok {
+		t.Errorf("expected owned rule of retired index pattern to be deleted")
+	}
+	if _, ok := api.rules["auto-db-rule"]; !ok {
+		t.Errorf("expected owned rule of index pattern missing from the listing but still existing to be kept")
+	}
+	if _, ok := api.rules["user-defined-rule"]; !ok {
+		t.Errorf("expected rules not owned by rubban to be kept")
+	}
+
+	owned := ownedRules{}
+	_ = json.Unmarshal(api.documents[".rubban/rules"], &owned)
+	expected := map[string]string{"auto-api-rule": "auto-api", "auto-web-rule": "auto-web", "auto-cache-rule": "auto-cache", "auto-db-rule": "auto-db"}
+	if !reflect.DeepEqual(owned.Rules, expected) {
+		t.Errorf("expected owned rules %v, got %v", expected, owned.Rules)
+	}
+}
+
+// TestProvisionRulesCheckFailure tests that no rule is deleted when checking if its index pattern is gone fails.
+func TestProvisionRulesCheckFailure(t *testing.T) {
+	api := &mockAPI{
+		documents: map[string][]byte{".rubban/rules": []byte(`{"rules":{"auto-old-rule":"auto-old"}}`)},
+		rules:     map[string]json.RawMessage{"auto-old-rule": json.RawMessage(`{}`)},
+		listErr:   fmt.Errorf("500 Internal Server Error"),
+	}
+	autoIdxPttrn := NewAutoIndexPattern(config.AutoIndexPattern{
+		Enabled: true,
+		GeneralPatterns: []config.GeneralPattern{{
+			Pattern:      "logs-?-*",
+			IDTemplate:   "auto-{{.group1}}",
+			RuleTemplate: "rule.json",
+		}},
+		Elasticsearch: config.AutoIndexPatternElasticsearch{Index: ".rubban", ID: "rules"},
+		Schedule:      "* * * * *",
+	}, api, log.Default())
+
+	result := store.NewResult()
+	autoIdxPttrn.provisionRules(context.Background(), []indexPattern{}, []kibana.IndexPattern{}, &result)
+
+	if !result.Failed() {
+		t.Errorf("expected failing check to be reported")
+	}
+	if _, ok := api.rules["auto-old-rule"]; !ok {
+		t.Errorf("expected owned rule to be kept when its index pattern can't be checked")
+	}
+}
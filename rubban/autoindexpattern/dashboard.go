@@ -0,0 +1,154 @@
+package autoindexpattern
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"text/template"
+
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+	"github.com/sherifabdlnaby/rubban/rubban/store"
+)
+
+// instantiateDashboard import the dashboard template of an index pattern's general pattern (if any), rewired to the
+// index pattern. Saved objects get IDs derived from the index pattern's, so ones that already exist are kept as is.
+// templates caches templates read during a run. Return the number of saved objects imported.
+func (a *AutoIndexPattern) instantiateDashboard(ctx context.Context, pattern indexPattern, templates map[string][]kibana.SavedObject) (int, error) {
+	if pattern.dashboardTemplate == "" {
+		return 0, nil
+	}
+
+	objects, ok := templates[pattern.dashboardTemplate]
+	if !ok {
+		ndjson, err := ioutil.ReadFile(pattern.dashboardTemplate)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read dashboard template, error: %s", err.Error())
+		}
+		objects, err = kibana.DecodeSavedObjects(ndjson)
+		if err != nil {
+			return 0, fmt.Errorf("failed to decode dashboard template [%s], error: %s", pattern.dashboardTemplate, err.Error())
+		}
+		templates[pattern.dashboardTemplate] = objects
+	}
+
+	instances, err := instantiateTemplate(objects, pattern.ID, pattern.data)
+	if err != nil {
+		return 0, fmt.Errorf("failed to render dashboard template [%s] for index pattern [%s], error: %s", pattern.dashboardTemplate, pattern.Title, err.Error())
+	}
+
+	imported, err := a.importInstances(ctx, instances)
+	if err != nil {
+		return imported, fmt.Errorf("failed to import dashboard template [%s] for index pattern [%s], error: %s", pattern.dashboardTemplate, pattern.Title, err.Error())
+	}
+
+	return imported, nil
+}
+
+// importInstances import saved objects without overwriting existing ones, and return the number of imported ones.
+func (a *AutoIndexPattern) importInstances(ctx context.Context, instances []kibana.SavedObject) (int, error) {
+	if len(instances) == 0 {
+		return 0, nil
+	}
+
+	ndjson := &bytes.Buffer{}
+	encoder := json.NewEncoder(ndjson)
+	for _, instance := range instances {
+		if err := encoder.Encode(instance); err != nil {
+			return 0, fmt.Errorf("failed to JSON marshaling saved object [%s]", instance.ID)
+		}
+	}
+
+	result, err := a.kibana.ImportSavedObjects(ctx, ndjson.Bytes(), false)
+	if err != nil {
+		return 0, err
+	}
+
+	// Conflicts are saved objects instantiated before.
+	skipped := make(map[string]bool)
+	for _, importError := range result.Errors {
+		if importError.Error.Type != "conflict" {
+			return 0, fmt.Errorf("failed to import %s [%s], error: %s", importError.Type, importError.ID, importError.Error.Type)
+		}
+		skipped[importError.Type+"/"+importError.ID] = true
+	}
+
+	imported := 0
+	for _, instance := range instances {
+		if skipped[instance.Type+"/"+instance.ID] {
+			continue
+		}
+		imported++
+		store.Record(ctx, store.Change{Action: store.ActionCreated, Type: instance.Type, ID: instance.ID, Title: instance.Title()})
+	}
+
+	return imported, nil
+}
+
+// instantiateTemplate return a copy of a template's saved objects (but index patterns) for an index pattern, with IDs
+// prefixed by the index pattern's ID, references to index patterns replaced by it, references between the template's
+// objects rewired to their copies, and titles and descriptions rendered with data.
+func instantiateTemplate(objects []kibana.SavedObject, indexPatternID string, data map[string]string) ([]kibana.SavedObject, error) {
+	newID := func(id string) string {
+		return indexPatternID + "-" + id
+	}
+
+	inTemplate := make(map[string]bool)
+	for _, object := range objects {
+		inTemplate[object.Type+"/"+object.ID] = true
+	}
+
+	instances := make([]kibana.SavedObject, 0, len(objects))
+	for _, object := range objects {
+		if object.Type == "index-pattern" {
+			continue
+		}
+
+		references := make([]kibana.Reference, 0, len(object.References))
+		for _, reference := range object.References {
+			switch {
+			case reference.Type == "index-pattern":
+				reference.ID = indexPatternID
+			case inTemplate[reference.Type+"/"+reference.ID]:
+				reference.ID = newID(reference.ID)
+			}
+			references = append(references, reference)
+		}
+
+		attributes := make(map[string]interface{})
+		if err := json.Unmarshal(object.Attributes, &attributes); err != nil {
+			return nil, fmt.Errorf("failed to decode %s [%s], error: %s", object.Type, object.ID, err.Error())
+		}
+		for _, key := range []string{"title", "description"} {
+			text, ok := attributes[key].(string)
+			if !ok || text == "" {
+				continue
+			}
+			tmpl, err := template.New(key).Option("missingkey=error").Parse(text)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s of %s [%s], error: %s", key, object.Type, object.ID, err.Error())
+			}
+			rendered, err := executeTemplate(tmpl, data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to render %s of %s [%s], error: %s", key, object.Type, object.ID, err.Error())
+			}
+			attributes[key] = rendered
+		}
+
+		buff, err := json.Marshal(attributes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to JSON marshaling %s [%s]", object.Type, object.ID)
+		}
+
+		instances = append(instances, kibana.SavedObject{
+			Type:             object.Type,
+			ID:               newID(object.ID),
+			Attributes:       buff,
+			References:       references,
+			MigrationVersion: object.MigrationVersion,
+		})
+	}
+
+	return instances, nil
+}
@@ -0,0 +1,72 @@
+package autoindexpattern
+
+import (
+	"context"
+	"sort"
+
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+	"github.com/sherifabdlnaby/rubban/rubban/utils"
+)
+
+//TimeFieldDrift is an index pattern whose time field is not the one its general pattern would create it with.
+type TimeFieldDrift struct {
+	kibana.IndexPattern
+	GeneralPattern string
+	Expected       string
+}
+
+//Missing Return index patterns that would be created for indices no index pattern covers yet, by general pattern
+//(as configured). Fails if indices of any general pattern couldn't be fetched.
+func (a *AutoIndexPattern) Missing(ctx context.Context) (map[string][]kibana.IndexPattern, error) {
+	d, err := a.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(d.errors) > 0 {
+		return nil, d.errors[0]
+	}
+
+	missing := make(map[string][]kibana.IndexPattern)
+	for _, generalPattern := range a.GeneralPatterns {
+		for _, indexPattern := range a.getIndexPattern(ctx, generalPattern, d) {
+			missing[generalPattern.raw] = append(missing[generalPattern.raw], indexPattern.IndexPattern)
+		}
+		sort.Slice(missing[generalPattern.raw], func(i, j int) bool {
+			return missing[generalPattern.raw][i].Title < missing[generalPattern.raw][j].Title
+		})
+	}
+
+	return missing, nil
+}
+
+//GeneralPatternOf Return the first general pattern covering an index pattern title, false if none does.
+func (a *AutoIndexPattern) GeneralPatternOf(title string) (GeneralPattern, bool) {
+	for _, generalPattern := range a.GeneralPatterns {
+		if utils.MatchGlob(generalPattern.Pattern, title) {
+			return generalPattern, true
+		}
+	}
+	return GeneralPattern{}, false
+}
+
+//WrongTimeFields Return index patterns covered by a general pattern, with a time field different than the one the
+//general pattern would create them with.
+func (a *AutoIndexPattern) WrongTimeFields(ctx context.Context, indexPatterns []kibana.IndexPattern) ([]TimeFieldDrift, error) {
+	drifts := make([]TimeFieldDrift, 0)
+	for _, indexPattern := range indexPatterns {
+		generalPattern, ok := a.GeneralPatternOf(indexPattern.Title)
+		if !ok {
+			continue
+		}
+
+		expected, err := a.timeFieldName(ctx, generalPattern, indexPattern.Title)
+		if err != nil {
+			return nil, err
+		}
+
+		if expected != indexPattern.TimeFieldName {
+			drifts = append(drifts, TimeFieldDrift{IndexPattern: indexPattern, GeneralPattern: generalPattern.raw, Expected: expected})
+		}
+	}
+	return drifts, nil
+}
@@ -0,0 +1,164 @@
+package autoindexpattern
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"text/template"
+
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+	"github.com/sherifabdlnaby/rubban/rubban/store"
+)
+
+// ownedRules is the Elasticsearch document mapping IDs of alerting rules Rubban created to the index pattern each was
+// created for, only owned rules are deleted when their index pattern no longer exists.
+type ownedRules struct {
+	Rules map[string]string `json:"rules"`
+}
+
+// ruleID return the ID of the rule created for an index pattern.
+func ruleID(indexPatternID string) string {
+	return indexPatternID + "-rule"
+}
+
+// provisionRules create the alerting rule of every provisioned index pattern (see provisioned) whose general pattern
+// has a rule template, and delete owned rules whose index pattern no longer exists. current must be the complete list of
+// index patterns (see discover), and every index pattern missing from it is confirmed gone before deleting its rule.
+// (skipped if no general pattern has a rule template)
+func (a *AutoIndexPattern) provisionRules(ctx context.Context, patterns []indexPattern, current []kibana.IndexPattern, result *store.Result) {
+	enabled := false
+	for _, generalPattern := range a.GeneralPatterns {
+		enabled = enabled || generalPattern.ruleTemplate != ""
+	}
+	if !enabled {
+		return
+	}
+
+	owned := ownedRules{}
+	version, err := a.kibana.GetDocument(ctx, a.rulesIndex, a.rulesID, &owned)
+	if err != nil {
+		err = fmt.Errorf("failed to get owned rules, error: %s", err.Error())
+		a.log.Errorw("Failed to get owned rules", "error", err.Error())
+		result.AddError(err)
+		return
+	}
+	if owned.Rules == nil {
+		owned.Rules = make(map[string]string)
+	}
+	changed := false
+
+	// 1- Create Rules of Index Patterns (rules that already exist are kept as is, and owned as their ID is derived from
+	// the index pattern's)
+	templates := make(map[string]*template.Template)
+	for _, pattern := range patterns {
+		if pattern.ruleTemplate == "" {
+			continue
+		}
+
+		rule, err := renderRule(pattern, templates)
+		if err != nil {
+			a.log.Errorw("Failed to render rule template", "indexPattern", pattern.Title, "error", err.Error())
+			result.AddError(err)
+			continue
+		}
+
+		id := ruleID(pattern.ID)
+		ok, err := a.kibana.CreateRule(ctx, id, rule)
+		if err != nil {
+			a.log.Errorw("Failed to create rule", "indexPattern", pattern.Title, "rule", id, "error", err.Error())
+			result.AddError(err)
+			continue
+		}
+		if !ok {
+			if owned.Rules[id] != pattern.ID {
+				owned.Rules[id] = pattern.ID
+				changed = true
+			}
+			continue
+		}
+
+		owned.Rules[id] = pattern.ID
+		changed = true
+		result.Add("rules created", 1)
+		store.Record(ctx, store.Change{Action: store.ActionCreated, Type: "rule", ID: id, Title: pattern.Title})
+	}
+
+	// 2- Delete Owned Rules of Index Patterns that no longer exist
+	exists := make(map[string]bool, len(current)+len(patterns))
+	for _, indexPattern := range current {
+		exists[indexPattern.ID] = true
+	}
+	for _, pattern := range patterns {
+		exists[pattern.ID] = true
+	}
+
+	for id, indexPatternID := range owned.Rules {
+		if exists[indexPatternID] {
+			continue
+		}
+
+		// Confirm the index pattern is really gone, pruning stops at the first check that fails so a rule is never
+		// deleted on an incomplete view of index patterns.
+		_, found, err := a.kibana.GetIndexPattern(ctx, indexPatternID)
+		if err != nil {
+			err = fmt.Errorf("failed to check index pattern [%s] of rule [%s], skipping deleting rules, error: %s", indexPatternID, id, err.Error())
+			a.log.Errorw("Failed to check index pattern of owned rule", "indexPatternID", indexPatternID, "rule", id, "error", err.Error())
+			result.AddError(err)
+			break
+		}
+		if found {
+			continue
+		}
+
+		err = a.kibana.DeleteRule(ctx, id)
+		if err != nil {
+			a.log.Errorw("Failed to delete rule of retired index pattern", "indexPatternID", indexPatternID, "rule", id, "error", err.Error())
+			result.AddError(err)
+			continue
+		}
+
+		delete(owned.Rules, id)
+		changed = true
+		result.Add("rules deleted", 1)
+		store.Record(ctx, store.Change{Action: store.ActionDeleted, Type: "rule", ID: id})
+		a.log.Infow("Deleted rule of retired index pattern", "indexPatternID", indexPatternID, "rule", id)
+	}
+
+	// 3- Record Owned Rules
+	if !changed {
+		return
+	}
+	err = a.kibana.IndexDocument(ctx, a.rulesIndex, a.rulesID, owned, version)
+	if err != nil {
+		err = fmt.Errorf("failed to record owned rules, error: %s", err.Error())
+		a.log.Errorw("Failed to record owned rules", "error", err.Error())
+		result.AddError(err)
+	}
+}
+
+// renderRule render the rule template of a new index pattern's general pattern. templates caches templates parsed
+// during a run.
+func renderRule(pattern indexPattern, templates map[string]*template.Template) (json.RawMessage, error) {
+	tmpl, ok := templates[pattern.ruleTemplate]
+	if !ok {
+		var err error
+		tmpl, err = template.ParseFiles(pattern.ruleTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse rule template, error: %s", err.Error())
+		}
+		tmpl.Option("missingkey=error")
+		templates[pattern.ruleTemplate] = tmpl
+	}
+
+	buff := bytes.Buffer{}
+	if err := tmpl.Execute(&buff, pattern.data); err != nil {
+		return nil, fmt.Errorf("failed to render rule template [%s] for index pattern [%s], error: %s", pattern.ruleTemplate, pattern.Title, err.Error())
+	}
+
+	if !json.Valid(buff.Bytes()) {
+		return nil, fmt.Errorf("rule template [%s] rendered invalid JSON for index pattern [%s]", pattern.ruleTemplate, pattern.Title)
+	}
+
+	return buff.Bytes(), nil
+}
@@ -3,26 +3,47 @@ package autoindexpattern
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/sherifabdlnaby/gpool"
+	"github.com/sherifabdlnaby/rubban/rubban/audit"
 	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+	"github.com/sherifabdlnaby/rubban/rubban/store"
 )
 
 //Run Run Auto Index Pattern creation task
-func (a *AutoIndexPattern) Run(ctx context.Context) {
+func (a *AutoIndexPattern) Run(ctx context.Context) store.Result {
+	result := store.NewResult()
+
+	// Fetch indices and index patterns once for all general patterns
+	d, err := a.discover(ctx)
+	if err != nil {
+		a.log.Errorw("Failed to discover indices and index patterns", "error", err.Error())
+		result.AddError(err)
+		return result
+	}
+	for _, err := range d.errors {
+		a.log.Errorw("Failed to discover indices, skipping their general patterns", "error", err.Error())
+		result.AddError(err)
+	}
+
 	//// Set for Found Patterns ( a set datastructes using Map )
-	newIndexPatterns := make(map[string]kibana.IndexPattern)
+	newIndexPatterns := make(map[string]indexPattern)
 
 	// Send Requests Concurrently
 	pool := gpool.NewPool(a.concurrency)
 	wg := sync.WaitGroup{}
+	mx := sync.Mutex{}
 	for _, generalPattern := range a.GeneralPatterns {
-		mx := sync.Mutex{}
+		generalPattern := generalPattern
+		if d.failed[generalPattern.Pattern] {
+			continue
+		}
 		wg.Add(1)
 		err := pool.Enqueue(ctx, func() {
 			defer wg.Done()
-			indexPatterns := a.getIndexPattern(ctx, generalPattern)
+			indexPatterns := a.getIndexPattern(ctx, generalPattern, d)
 
 			// Add Result to global Result
 			mx.Lock()
@@ -41,19 +62,74 @@ func (a *AutoIndexPattern) Run(ctx context.Context) {
 	wg.Wait()
 	pool.Stop()
 
-	// Create List from The Map Set we create
+	// Create List from The Map Set we create. A rendered ID that is already used by an existing index pattern with
+	// another title, or by another new index pattern, would overwrite it (and the two would overwrite each other every
+	// run), so the index pattern is escaped. Titles are sorted so the same one keeps the ID every run.
+	ids := make(map[string]string, len(d.indexPatterns))
+	for _, indexPattern := range d.indexPatterns {
+		ids[indexPattern.ID] = indexPattern.Title
+	}
+	titles := make([]string, 0, len(newIndexPatterns))
+	for title := range newIndexPatterns {
+		titles = append(titles, title)
+	}
+	sort.Strings(titles)
+
 	indexPatterns := make([]kibana.IndexPattern, 0)
-	for _, pattern := range newIndexPatterns {
-		indexPatterns = append(indexPatterns, pattern)
+	indices := make(map[string][]string)
+	for _, title := range titles {
+		pattern := newIndexPatterns[title]
+		if pattern.ID != "" {
+			if conflict, ok := ids[pattern.ID]; ok && conflict != title {
+				a.log.Warnw("index pattern rendered an ID used by another index pattern, escaping it...",
+					"id", pattern.ID, "indexPattern", title, "conflictsWith", conflict)
+				delete(newIndexPatterns, title)
+				continue
+			}
+			ids[pattern.ID] = title
+		}
+		indexPatterns = append(indexPatterns, pattern.IndexPattern)
+		indices[title] = pattern.indices
 	}
 
-	err := a.kibana.BulkCreateIndexPattern(ctx, indexPatterns)
+	err = a.kibana.BulkCreateIndexPattern(audit.WithIndices(ctx, indices), indexPatterns)
 	if err != nil {
 		a.log.Errorw("Failed to bulk create new index patterns", "error", err.Error())
+		result.AddError(err)
+		return result
+	}
+
+	for _, pattern := range newIndexPatterns {
+		store.Record(ctx, store.Change{
+			Action:  store.ActionCreated,
+			Type:    "index-pattern",
+			ID:      pattern.ID,
+			Title:   pattern.Title,
+			Indices: pattern.indices,
+		})
 	}
 
+	result.Add(store.ActionCreated, len(newIndexPatterns))
 	a.log.Infow(fmt.Sprintf("Successfully created %d Index Patterns.", len(newIndexPatterns)), "Index Patterns", newIndexPatterns)
 
+	// Instantiate Dashboard Templates of New and Existing Index Patterns (saved objects instantiated before are kept)
+	provisioned := a.provisioned(d.indexPatterns, newIndexPatterns)
+	templates := make(map[string][]kibana.SavedObject)
+	for _, pattern := range provisioned {
+		templated, err := a.instantiateDashboard(ctx, pattern, templates)
+		if err != nil {
+			a.log.Errorw("Failed to instantiate dashboard template", "indexPattern", pattern.Title, "error", err.Error())
+			result.AddError(err)
+		}
+		if templated > 0 {
+			result.Add("templated", templated)
+		}
+	}
+
+	// Provision Alerting Rules of New and Existing Index Patterns, and delete ones of retired index patterns
+	a.provisionRules(ctx, provisioned, d.indexPatterns, &result)
+
+	return result
 }
 
 //Name Return Task Name
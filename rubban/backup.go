@@ -0,0 +1,85 @@
+package rubban
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sherifabdlnaby/rubban/rubban/backup"
+)
+
+//Backup Export saved objects to a new snapshot and delete old snapshots (as the backup task does), return the exit
+//code of the backup command.
+func Backup(out io.Writer) int {
+	r, s, close, err := newCommand()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to backup saved objects, %s\n", err.Error())
+		return 1
+	}
+	defer close()
+
+	b := backup.NewBackup(s.config.Backup, s.api, r.logger.Extend("backup"))
+
+	snapshot, exported, err := b.Snapshot(r.mainCtx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to backup saved objects, %s\n", err.Error())
+		return 1
+	}
+	fmt.Fprintf(out, "Saved %d saved object(s) to %s\n", exported, snapshot.Path)
+
+	deleted, err := b.Rotate()
+	for _, snapshot := range deleted {
+		fmt.Fprintf(out, "Deleted old snapshot %s\n", snapshot.Path)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to rotate snapshots, %s\n", err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+//Restore Import saved objects of the named snapshot (or list snapshots if list is true), return the exit code of the
+//restore command.
+func Restore(out io.Writer, name string, list bool) int {
+	r, s, close, err := newCommand()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to restore saved objects, %s\n", err.Error())
+		return 1
+	}
+	defer close()
+
+	b := backup.NewBackup(s.config.Backup, s.api, r.logger.Extend("backup"))
+
+	if list {
+		snapshots, err := b.Snapshots()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to list snapshots, %s\n", err.Error())
+			return 1
+		}
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "SNAPSHOT\tTIME\tSIZE")
+		for _, snapshot := range snapshots {
+			fmt.Fprintf(w, "%s\t%s\t%d\n", snapshot.Name, snapshot.Time.Format(time.RFC3339), snapshot.Size)
+		}
+		_ = w.Flush()
+		return 0
+	}
+
+	snapshot, err := b.Find(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to restore saved objects, %s\n", err.Error())
+		return 1
+	}
+
+	result, err := b.Restore(r.mainCtx, snapshot)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to restore saved objects from %s, %s\n", snapshot.Path, err.Error())
+		return 1
+	}
+	fmt.Fprintf(out, "Restored %d saved object(s) from %s\n", result.SuccessCount, snapshot.Path)
+
+	return 0
+}
@@ -0,0 +1,212 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sherifabdlnaby/rubban/config"
+	"github.com/sherifabdlnaby/rubban/log"
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+)
+
+// Snapshots are named snapshot-<UTC time>.ndjson with millisecond precision, so they sort by time. (older snapshots
+// with second precision are parsed as well)
+const (
+	snapshotPrefix = "snapshot-"
+	snapshotExt    = ".ndjson"
+	timeLayout     = "20060102T150405.000Z"
+)
+
+//Latest is the name that refers to the newest snapshot.
+const Latest = "latest"
+
+//Backup hold attributes for a Backup loaded from config.
+type Backup struct {
+	name   string
+	Path   string
+	types  []string
+	keep   int
+	kibana kibana.API
+	log    log.Logger
+}
+
+//Snapshot is a file of saved objects exported from Kibana.
+type Snapshot struct {
+	Name string
+	Path string
+	Time time.Time
+	Size int64
+}
+
+//NewBackup Constructor
+func NewBackup(config config.Backup, kibana kibana.API, log log.Logger) *Backup {
+	return &Backup{
+		name:   "Backup",
+		Path:   config.Path,
+		types:  config.Types,
+		keep:   config.Keep,
+		kibana: kibana,
+		log:    log,
+	}
+}
+
+//Snapshot Export saved objects to a new snapshot, and return it with the number of saved objects exported.
+func (b *Backup) Snapshot(ctx context.Context) (Snapshot, int, error) {
+	ndjson, err := b.kibana.ExportSavedObjects(ctx, b.types)
+	if err != nil {
+		return Snapshot{}, 0, err
+	}
+
+	objects, err := kibana.DecodeSavedObjects(ndjson)
+	if err != nil {
+		return Snapshot{}, 0, fmt.Errorf("failed to decode exported saved objects, error: %s", err.Error())
+	}
+
+	err = os.MkdirAll(b.Path, 0750)
+	if err != nil {
+		return Snapshot{}, 0, fmt.Errorf("failed to create backup directory, error: %s", err.Error())
+	}
+
+	// Write to a temporary file first, so a snapshot is never partially written.
+	file, err := ioutil.TempFile(b.Path, ".snapshot-")
+	if err != nil {
+		return Snapshot{}, 0, fmt.Errorf("failed to create snapshot, error: %s", err.Error())
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(ndjson)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return Snapshot{}, 0, fmt.Errorf("failed to write snapshot, error: %s", err.Error())
+	}
+
+	now := time.Now().UTC()
+	snapshot := Snapshot{
+		Name: snapshotPrefix + now.Format(timeLayout) + snapshotExt,
+		Time: now.Truncate(time.Millisecond),
+		Size: int64(len(ndjson)),
+	}
+	snapshot.Path = filepath.Join(b.Path, snapshot.Name)
+
+	// Link (unlike rename) fails if a snapshot with the same name exists, so a snapshot is never overwritten.
+	err = os.Link(file.Name(), snapshot.Path)
+	if os.IsExist(err) {
+		return Snapshot{}, 0, fmt.Errorf("snapshot [%s] already exists", snapshot.Name)
+	}
+	if err != nil {
+		return Snapshot{}, 0, fmt.Errorf("failed to write snapshot, error: %s", err.Error())
+	}
+
+	return snapshot, len(objects), nil
+}
+
+//Snapshots Return snapshots in Path, newest first.
+func (b *Backup) Snapshots() ([]Snapshot, error) {
+	files, err := ioutil.ReadDir(b.Path)
+	if os.IsNotExist(err) {
+		return []Snapshot{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots, error: %s", err.Error())
+	}
+
+	snapshots := make([]Snapshot, 0)
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasPrefix(name, snapshotPrefix) || !strings.HasSuffix(name, snapshotExt) {
+			continue
+		}
+
+		// Parsing accepts names with and without milliseconds
+		snapshotTime, err := time.Parse("20060102T150405Z", strings.TrimSuffix(strings.TrimPrefix(name, snapshotPrefix), snapshotExt))
+		if err != nil {
+			continue
+		}
+
+		snapshots = append(snapshots, Snapshot{Name: name, Path: filepath.Join(b.Path, name), Time: snapshotTime, Size: file.Size()})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Time.After(snapshots[j].Time) })
+	return snapshots, nil
+}
+
+//Rotate Delete snapshots but the newest Keep ones (none if Keep is 0), and return deleted ones.
+func (b *Backup) Rotate() ([]Snapshot, error) {
+	if b.keep == 0 {
+		return []Snapshot{}, nil
+	}
+
+	snapshots, err := b.Snapshots()
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := make([]Snapshot, 0)
+	for i := b.keep; i < len(snapshots); i++ {
+		err := os.Remove(snapshots[i].Path)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete snapshot [%s], error: %s", snapshots[i].Name, err.Error())
+		}
+		deleted = append(deleted, snapshots[i])
+	}
+
+	return deleted, nil
+}
+
+//Find Return the snapshot with name in Path, the newest snapshot if name is Latest, or the file at name if it's a path.
+func (b *Backup) Find(name string) (Snapshot, error) {
+	snapshots, err := b.Snapshots()
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	if name == Latest {
+		if len(snapshots) == 0 {
+			return Snapshot{}, fmt.Errorf("no snapshots found in %s", b.Path)
+		}
+		return snapshots[0], nil
+	}
+
+	for _, snapshot := range snapshots {
+		if snapshot.Name == name {
+			return snapshot, nil
+		}
+	}
+
+	file, err := os.Stat(name)
+	if err != nil || file.IsDir() {
+		return Snapshot{}, fmt.Errorf("snapshot [%s] not found in %s", name, b.Path)
+	}
+	return Snapshot{Name: file.Name(), Path: name, Time: file.ModTime(), Size: file.Size()}, nil
+}
+
+//Restore Import saved objects of a snapshot to Kibana, overwriting existing ones.
+func (b *Backup) Restore(ctx context.Context, snapshot Snapshot) (kibana.ImportResult, error) {
+	ndjson, err := ioutil.ReadFile(snapshot.Path)
+	if err != nil {
+		return kibana.ImportResult{}, fmt.Errorf("failed to read snapshot [%s], error: %s", snapshot.Name, err.Error())
+	}
+
+	result, err := b.kibana.ImportSavedObjects(ctx, ndjson, true)
+	if err != nil {
+		return result, err
+	}
+
+	if !result.Success {
+		failed := make([]string, 0, len(result.Errors))
+		for _, importError := range result.Errors {
+			failed = append(failed, fmt.Sprintf("%s [%s]: %s", importError.Type, importError.ID, importError.Error.Type))
+		}
+		return result, fmt.Errorf("failed to import %d saved object(s), %s", len(result.Errors), strings.Join(failed, ", "))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,123 @@
+package backup
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sherifabdlnaby/rubban/config"
+	"github.com/sherifabdlnaby/rubban/log"
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+)
+
+const export = `{"attributes":{"title":"logs-*"},"id":"logs","references":[],"type":"index-pattern"}
+{"attributes":{"title":"metrics-*"},"id":"metrics","references":[],"type":"index-pattern"}
+{"exportedCount":2,"missingRefCount":0,"missingReferences":[]}
+`
+
+type mockAPI struct {
+	kibana.API
+	imported []byte
+}
+
+func (m *mockAPI) ExportSavedObjects(ctx context.Context, types []string) ([]byte, error) {
+	return []byte(export), nil
+}
+
+func (m *mockAPI) ImportSavedObjects(ctx context.Context, ndjson []byte, overwrite bool) (kibana.ImportResult, error) {
+	m.imported = ndjson
+	objects, err := kibana.DecodeSavedObjects(ndjson)
+	return kibana.ImportResult{Success: true, SuccessCount: len(objects)}, err
+}
+
+func TestBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rubban-backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Old snapshots, and files that are not snapshots
+	for _, name := range []string{"snapshot-20200101T000000Z.ndjson", "snapshot-20200102T000000Z.ndjson", "notes.txt"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("{}"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	api := &mockAPI{}
+	b := NewBackup(config.Backup{Path: dir, Types: []string{"index-pattern"}, Keep: 2}, api, log.Default())
+
+	result := b.Run(context.Background())
+	if result.Failed() || result.Counts["exported"] != 2 || result.Counts["rotated"] != 1 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	snapshots, err := b.Snapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 2 || snapshots[1].Name != "snapshot-20200102T000000Z.ndjson" {
+		t.Fatalf("Expected the newest 2 snapshots to be kept, got %+v", snapshots)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Errorf("Expected files that are not snapshots to be kept")
+	}
+
+	latest, err := b.Find(Latest)
+	if err != nil || latest.Name != snapshots[0].Name || time.Since(latest.Time) > time.Minute {
+		t.Fatalf("Expected latest snapshot to be %s, got %+v, %v", snapshots[0].Name, latest, err)
+	}
+
+	importResult, err := b.Restore(context.Background(), latest)
+	if err != nil || importResult.SuccessCount != 2 || string(api.imported) != export {
+		t.Errorf("Expected snapshot to be imported as exported, got %+v, %v", importResult, err)
+	}
+
+	if _, err := b.Find("snapshot-20200101T000000Z.ndjson"); err == nil {
+		t.Errorf("Expected rotated snapshot to be deleted")
+	}
+}
+
+func TestSnapshotNames(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rubban-backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	b := NewBackup(config.Backup{Path: dir, Types: []string{"index-pattern"}}, &mockAPI{}, log.Default())
+
+	// Snapshots taken in the same second get different names, and a snapshot taken in the same millisecond fails
+	// instead of overwriting the other one.
+	names := make(map[string]bool)
+	for i := 0; i < 20; i++ {
+		snapshot, _, err := b.Snapshot(context.Background())
+		if err != nil {
+			if !strings.Contains(err.Error(), "already exists") {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if names[snapshot.Name] {
+			t.Fatalf("Expected snapshot [%s] not to be overwritten", snapshot.Name)
+		}
+		names[snapshot.Name] = true
+	}
+
+	snapshots, err := b.Snapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != len(names) {
+		t.Errorf("Expected %d snapshots, got %d", len(names), len(snapshots))
+	}
+	for i := 1; i < len(snapshots); i++ {
+		if !snapshots[i-1].Time.After(snapshots[i].Time) {
+			t.Errorf("Expected snapshots to be sorted newest first by their milliseconds, got %+v", snapshots)
+		}
+	}
+}
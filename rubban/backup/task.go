@@ -0,0 +1,40 @@
+package backup
+
+import (
+	"context"
+
+	"github.com/sherifabdlnaby/rubban/rubban/store"
+)
+
+//Run Run Backup task
+func (b *Backup) Run(ctx context.Context) store.Result {
+	result := store.NewResult()
+
+	// 1- Export Saved Objects to a new Snapshot
+	snapshot, exported, err := b.Snapshot(ctx)
+	if err != nil {
+		b.log.Errorw("Failed to backup saved objects", "path", b.Path, "error", err.Error())
+		result.AddError(err)
+		return result
+	}
+	result.Add("exported", exported)
+	b.log.Infow("Saved snapshot", "snapshot", snapshot.Path, "exported", exported)
+
+	// 2- Delete Old Snapshots
+	deleted, err := b.Rotate()
+	if err != nil {
+		b.log.Errorw("Failed to rotate snapshots", "path", b.Path, "error", err.Error())
+		result.AddError(err)
+	}
+	result.Add("rotated", len(deleted))
+	for _, snapshot := range deleted {
+		b.log.Debugw("Deleted old snapshot", "snapshot", snapshot.Path)
+	}
+
+	return result
+}
+
+//Name Return Task Name
+func (b *Backup) Name() string {
+	return b.name
+}
@@ -0,0 +1,46 @@
+package rubban
+
+import (
+	"fmt"
+
+	"github.com/sherifabdlnaby/rubban/config"
+	"github.com/sherifabdlnaby/rubban/log"
+	"github.com/sherifabdlnaby/rubban/rubban/audit"
+)
+
+// newCommand Load config and build a state with a Kibana API client and tasks for a one-off command (nothing is
+// scheduled), changes made through the API are audited if audit is enabled. close releases what it opened.
+func newCommand() (r *Rubban, s *state, close func(), err error) {
+	cfg, err := config.Load("Rubban")
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to load configuration: %s", err.Error())
+	}
+
+	r = New()
+	r.logger = log.NewZapLoggerImpl("Rubban", cfg.Logging)
+	close = func() {
+		if r.audit != nil {
+			_ = r.audit.Close()
+		}
+		r.cancel()
+	}
+
+	s = &state{config: cfg, context: r.mainCtx}
+	err = r.initKibanaClient(s)
+	if err != nil {
+		close()
+		return nil, nil, nil, fmt.Errorf("failed to initialize Kibana API client: %s", err.Error())
+	}
+
+	if cfg.Audit.Enabled {
+		r.audit, err = audit.NewLog(cfg.Audit, s.api)
+		if err != nil {
+			close()
+			return nil, nil, nil, fmt.Errorf("failed to initialize audit log: %s", err.Error())
+		}
+		s.api = audit.NewAPI(s.api, r.audit, cfg.Kibana.Space, r.logger.Extend("audit"))
+	}
+
+	r.initTasks(s, log.Nop())
+	return r, s, close, nil
+}
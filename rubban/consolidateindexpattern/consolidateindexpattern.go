@@ -0,0 +1,307 @@
+package consolidateindexpattern
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sherifabdlnaby/rubban/config"
+	"github.com/sherifabdlnaby/rubban/log"
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+	"github.com/sherifabdlnaby/rubban/rubban/store"
+	"github.com/sherifabdlnaby/rubban/rubban/utils"
+)
+
+//Kinds of a Group
+const (
+	KindDuplicate = "duplicate"
+	KindOverlap   = "overlap"
+)
+
+//ConsolidateIndexPattern hold attributes for a ConsolidateIndexPattern loaded from config.
+type ConsolidateIndexPattern struct {
+	name           string
+	Merge          string
+	referenceTypes []string
+	kibana         kibana.API
+	log            log.Logger
+}
+
+//NewConsolidateIndexPattern Constructor
+func NewConsolidateIndexPattern(config config.ConsolidateIndexPattern, kibana kibana.API, log log.Logger) *ConsolidateIndexPattern {
+	return &ConsolidateIndexPattern{
+		name:           "Consolidate Index Pattern",
+		Merge:          config.Merge,
+		referenceTypes: config.ReferenceTypes,
+		kibana:         kibana,
+		log:            log,
+	}
+}
+
+//Group of index patterns that can be merged into a Survivor. Duplicates have the same title as the survivor, and
+//overlapping index patterns match a subset of the indices the survivor matches.
+type Group struct {
+	Kind     string
+	Survivor kibana.IndexPattern
+	Others   []kibana.IndexPattern
+}
+
+// findGroups find duplicate and overlapping index patterns of the configured space. defaultID is preferred as a
+// survivor of duplicates.
+func (c *ConsolidateIndexPattern) findGroups(ctx context.Context, defaultID string) ([]Group, error) {
+	objects, err := c.kibana.FindSavedObjects(ctx, kibana.SavedObjectsQuery{Types: []string{"index-pattern"}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get index patterns, error: %s", err.Error())
+	}
+
+	indexPatterns := make([]kibana.IndexPattern, 0, len(objects))
+	for _, object := range objects {
+		indexPattern := kibana.IndexPattern{}
+		if err := json.Unmarshal(object.Attributes, &indexPattern); err != nil {
+			return nil, fmt.Errorf("failed to decode index pattern [%s], error: %s", object.ID, err.Error())
+		}
+		indexPattern.ID = object.ID
+		indexPatterns = append(indexPatterns, indexPattern)
+	}
+
+	indices, err := c.kibana.Indices(ctx, "*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get indices, error: %s", err.Error())
+	}
+
+	groups := duplicates(indexPatterns, defaultID)
+
+	// Only survivors of duplicates are checked for overlaps.
+	unique := make([]kibana.IndexPattern, 0, len(indexPatterns))
+	removed := make(map[string]bool)
+	for _, group := range groups {
+		for _, other := range group.Others {
+			removed[other.ID] = true
+		}
+	}
+	for _, indexPattern := range indexPatterns {
+		if !removed[indexPattern.ID] {
+			unique = append(unique, indexPattern)
+		}
+	}
+
+	return append(groups, overlaps(unique, indices)...), nil
+}
+
+// compatible check if index patterns a and b have the same time field and attributes, only these are merged as
+// saved objects re-pointed from one to the other would break otherwise.
+func compatible(a, b kibana.IndexPattern) bool {
+	a.ID, a.Title, a.Name = "", "", ""
+	b.ID, b.Title, b.Name = "", "", ""
+	return a == b
+}
+
+// catchAll check if title matches any index (e.g. *, *:*), such index patterns are never survivors of overlaps as
+// every other index pattern would be merged into them.
+func catchAll(title string) bool {
+	for _, pattern := range strings.Split(title, ",") {
+		if strings.Trim(strings.TrimSpace(pattern), "*:") == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// duplicates group compatible index patterns with the same title, the survivor is the default index pattern if it's one
+// of them, otherwise the one with the smallest ID.
+func duplicates(indexPatterns []kibana.IndexPattern, defaultID string) []Group {
+	byTitle := make(map[string][]kibana.IndexPattern)
+	titles := make([]string, 0)
+	for _, indexPattern := range indexPatterns {
+		if _, ok := byTitle[indexPattern.Title]; !ok {
+			titles = append(titles, indexPattern.Title)
+		}
+		byTitle[indexPattern.Title] = append(byTitle[indexPattern.Title], indexPattern)
+	}
+	sort.Strings(titles)
+
+	groups := make([]Group, 0)
+	for _, title := range titles {
+		same := byTitle[title]
+		if len(same) < 2 {
+			continue
+		}
+
+		sort.Slice(same, func(i, j int) bool {
+			if same[i].ID == defaultID || same[j].ID == defaultID {
+				return same[i].ID == defaultID
+			}
+			return same[i].ID < same[j].ID
+		})
+
+		// Index patterns with the same title but a different time field or attributes are kept apart.
+		compatibleGroups := make([]Group, 0)
+		for _, indexPattern := range same {
+			grouped := false
+			for i := range compatibleGroups {
+				if compatible(compatibleGroups[i].Survivor, indexPattern) {
+					compatibleGroups[i].Others = append(compatibleGroups[i].Others, indexPattern)
+					grouped = true
+					break
+				}
+			}
+			if !grouped {
+				compatibleGroups = append(compatibleGroups, Group{Kind: KindDuplicate, Survivor: indexPattern})
+			}
+		}
+
+		for _, group := range compatibleGroups {
+			if len(group.Others) > 0 {
+				groups = append(groups, group)
+			}
+		}
+	}
+
+	return groups
+}
+
+// overlaps group every index pattern matching a subset of the indices another compatible index pattern matches, with
+// the tightest index pattern covering it as a survivor. (index patterns matching no indices are skipped, and catch-all
+// index patterns never survive)
+func overlaps(indexPatterns []kibana.IndexPattern, indices []kibana.Index) []Group {
+
+	// Indices of every index pattern, and index patterns of every index
+	matched := make([]map[string]bool, len(indexPatterns))
+	coveredBy := make(map[string][]int)
+	for i, indexPattern := range indexPatterns {
+		matched[i] = make(map[string]bool)
+		set := utils.NewGlobSet(indexPattern.Title)
+		for _, index := range indices {
+			if set.Match(index.Name) {
+				matched[i][index.Name] = true
+				coveredBy[index.Name] = append(coveredBy[index.Name], i)
+			}
+		}
+	}
+
+	// contains check if a covers b, equal sets are broken by title so only one covers the other.
+	contains := func(a, b int) bool {
+		if len(matched[a]) != len(matched[b]) {
+			return len(matched[a]) > len(matched[b])
+		}
+		return indexPatterns[a].Title < indexPatterns[b].Title
+	}
+
+	survivors := make(map[int]int)
+	for i := range indexPatterns {
+		if len(matched[i]) == 0 {
+			continue
+		}
+
+		// Candidates are index patterns covering every index of i, starting with ones covering any of them.
+		var anyIndex string
+		for index := range matched[i] {
+			anyIndex = index
+			break
+		}
+
+		survivor := -1
+		for _, candidate := range coveredBy[anyIndex] {
+			if candidate == i || !contains(candidate, i) || catchAll(indexPatterns[candidate].Title) ||
+				!compatible(indexPatterns[candidate], indexPatterns[i]) {
+				continue
+			}
+
+			subset := true
+			for index := range matched[i] {
+				if !matched[candidate][index] {
+					subset = false
+					break
+				}
+			}
+
+			if subset && (survivor == -1 || contains(survivor, candidate)) {
+				survivor = candidate
+			}
+		}
+
+		if survivor != -1 {
+			survivors[i] = survivor
+		}
+	}
+
+	// Survivors that overlap themselves are merged too, so follow the chain to the widest one.
+	bySurvivor := make(map[int][]kibana.IndexPattern)
+	for i, survivor := range survivors {
+		for {
+			next, ok := survivors[survivor]
+			if !ok {
+				break
+			}
+			survivor = next
+		}
+		bySurvivor[survivor] = append(bySurvivor[survivor], indexPatterns[i])
+	}
+
+	groups := make([]Group, 0, len(bySurvivor))
+	for survivor, others := range bySurvivor {
+		sort.Slice(others, func(i, j int) bool { return others[i].Title < others[j].Title })
+		groups = append(groups, Group{Kind: KindOverlap, Survivor: indexPatterns[survivor], Others: others})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Survivor.Title < groups[j].Survivor.Title })
+
+	return groups
+}
+
+// merge re-point references of other to survivor in saved objects, and the default index pattern if it's other, then
+// delete other. Other is kept if any reference couldn't be re-pointed.
+func (c *ConsolidateIndexPattern) merge(ctx context.Context, survivor, other kibana.IndexPattern, defaultID string) error {
+	// Both must exist in the space, re-pointing to a missing survivor breaks references, and deleting a missing other
+	// is a no-op that shouldn't count as merged.
+	for _, indexPattern := range []kibana.IndexPattern{survivor, other} {
+		_, found, err := c.kibana.GetIndexPattern(ctx, indexPattern.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get index pattern [%s], error: %s", indexPattern.ID, err.Error())
+		}
+		if !found {
+			return fmt.Errorf("index pattern [%s] not found", indexPattern.ID)
+		}
+	}
+
+	objects, err := c.kibana.FindSavedObjects(ctx, kibana.SavedObjectsQuery{
+		Types:        c.referenceTypes,
+		HasReference: &kibana.Reference{Type: "index-pattern", ID: other.ID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find saved objects referencing index pattern [%s], error: %s", other.ID, err.Error())
+	}
+
+	for _, object := range objects {
+		references := make([]kibana.Reference, 0, len(object.References))
+		for _, reference := range object.References {
+			if reference.Type == "index-pattern" && reference.ID == other.ID {
+				reference.ID = survivor.ID
+			}
+			references = append(references, reference)
+		}
+
+		err := c.kibana.UpdateSavedObject(ctx, kibana.SavedObject{Type: object.Type, ID: object.ID, References: references})
+		if err != nil {
+			return fmt.Errorf("failed to re-point %s [%s] to index pattern [%s], error: %s", object.Type, object.ID, survivor.ID, err.Error())
+		}
+		store.Record(ctx, store.Change{Action: store.ActionUpdated, Type: object.Type, ID: object.ID, Title: object.Title()})
+	}
+
+	if defaultID == other.ID {
+		err := c.kibana.SetDefaultIndexPattern(ctx, survivor.ID)
+		if err != nil {
+			return fmt.Errorf("failed to set default index pattern to [%s], error: %s", survivor.ID, err.Error())
+		}
+		store.Record(ctx, store.Change{Action: store.ActionUpdated, Type: "config", ID: "defaultIndex", Title: survivor.ID})
+	}
+
+	err = c.kibana.DeleteIndexPattern(ctx, other.ID)
+	if err != nil {
+		return err
+	}
+	store.Record(ctx, store.Change{Action: store.ActionDeleted, Type: "index-pattern", ID: other.ID, Title: other.Title})
+
+	return nil
+}
@@ -0,0 +1,188 @@
+package consolidateindexpattern
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/sherifabdlnaby/rubban/config"
+	"github.com/sherifabdlnaby/rubban/log"
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+	"github.com/sherifabdlnaby/rubban/rubban/store"
+)
+
+type mockAPI struct {
+	kibana.API
+	indices       []kibana.Index
+	indexPatterns map[string]kibana.IndexPattern
+	objects       map[string]kibana.SavedObject
+	defaultID     string
+	findErr       error
+}
+
+func (m *mockAPI) Indices(ctx context.Context, filter string) ([]kibana.Index, error) {
+	return m.indices, nil
+}
+
+func (m *mockAPI) GetIndexPattern(ctx context.Context, id string) (kibana.IndexPattern, bool, error) {
+	indexPattern, ok := m.indexPatterns[id]
+	return indexPattern, ok, nil
+}
+
+func (m *mockAPI) DeleteIndexPattern(ctx context.Context, id string) error {
+	delete(m.indexPatterns, id)
+	return nil
+}
+
+func (m *mockAPI) FindSavedObjects(ctx context.Context, query kibana.SavedObjectsQuery) ([]kibana.SavedObject, error) {
+	objects := make([]kibana.SavedObject, 0)
+	if query.HasReference != nil && m.findErr != nil {
+		return nil, m.findErr
+	}
+	if query.HasReference == nil {
+		for _, indexPattern := range m.indexPatterns {
+			attributes, _ := json.Marshal(indexPattern)
+			objects = append(objects, kibana.SavedObject{Type: "index-pattern", ID: indexPattern.ID, Attributes: attributes})
+		}
+		return objects, nil
+	}
+	for _, object := range m.objects {
+		for _, reference := range object.References {
+			if reference.Type == query.HasReference.Type && reference.ID == query.HasReference.ID {
+				objects = append(objects, object)
+				break
+			}
+		}
+	}
+	return objects, nil
+}
+
+func (m *mockAPI) UpdateSavedObject(ctx context.Context, object kibana.SavedObject) error {
+	current := m.objects[object.ID]
+	current.References = object.References
+	m.objects[object.ID] = current
+	return nil
+}
+
+func (m *mockAPI) DefaultIndexPattern(ctx context.Context) (string, error) {
+	return m.defaultID, nil
+}
+
+func (m *mockAPI) SetDefaultIndexPattern(ctx context.Context, id string) error {
+	m.defaultID = id
+	return nil
+}
+
+func newMockAPI() *mockAPI {
+	return &mockAPI{
+		indices: []kibana.Index{
+			{Name: "logs-api-prod-2020.01.01"}, {Name: "logs-api-dev-2020.01.01"}, {Name: "metrics-2020.01.01"},
+		},
+		indexPatterns: map[string]kibana.IndexPattern{
+			"api":      {ID: "api", Title: "logs-api-*"},
+			"api-prod": {ID: "api-prod", Title: "logs-api-prod-*"},
+			"logs":     {ID: "logs", Title: "logs-*"},
+			"metrics":  {ID: "metrics", Title: "metrics-*"},
+			"metrics2": {ID: "metrics2", Title: "metrics-*"},
+			"metrics3": {ID: "metrics3", Title: "metrics-*", TimeFieldName: "@timestamp"},
+			"api-dev":  {ID: "api-dev", Title: "logs-api-dev-*", FieldFormatMap: `{"bytes":{"id":"bytes"}}`},
+			"all":      {ID: "all", Title: "*"},
+			"empty":    {ID: "empty", Title: "empty-*"},
+		},
+		objects: map[string]kibana.SavedObject{
+			"vis": {Type: "visualization", ID: "vis", References: []kibana.Reference{
+				{Type: "index-pattern", ID: "api-prod", Name: "kibanaSavedObjectMeta.searchSourceJSON.index"},
+			}},
+			"dashboard": {Type: "dashboard", ID: "dashboard", References: []kibana.Reference{
+				{Type: "visualization", ID: "vis", Name: "panel_0"}, {Type: "index-pattern", ID: "metrics2", Name: "filter_0"},
+			}},
+		},
+		defaultID: "metrics2",
+	}
+}
+
+func TestFindGroups(t *testing.T) {
+	api := newMockAPI()
+	c := NewConsolidateIndexPattern(config.ConsolidateIndexPattern{Merge: "none"}, api, log.Default())
+
+	groups, err := c.findGroups(context.Background(), api.defaultID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual := make(map[string][]string)
+	for _, group := range groups {
+		for _, other := range group.Others {
+			actual[group.Kind+" "+group.Survivor.ID] = append(actual[group.Kind+" "+group.Survivor.ID], other.ID)
+		}
+		sort.Strings(actual[group.Kind+" "+group.Survivor.ID])
+	}
+
+	// Default index pattern survives duplicates, overlaps are merged into the widest index pattern. Index patterns with
+	// a different time field or attributes, and the catch-all index pattern are never grouped.
+	expected := map[string][]string{
+		KindDuplicate + " metrics2": {"metrics"},
+		KindOverlap + " logs":       {"api", "api-prod"},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected groups %v, got %v", expected, actual)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	api := newMockAPI()
+	c := NewConsolidateIndexPattern(config.ConsolidateIndexPattern{Merge: "duplicates", ReferenceTypes: []string{"visualization", "dashboard"}}, api, log.Default())
+
+	result := c.Run(context.Background())
+	if result.Failed() || result.Counts["duplicates"] != 1 || result.Counts["overlapping"] != 2 || result.Counts[store.ActionDeleted] != 1 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if _, ok := api.indexPatterns["metrics"]; ok {
+		t.Errorf("Expected duplicate index pattern to be deleted")
+	}
+	if _, ok := api.indexPatterns["api-prod"]; !ok {
+		t.Errorf("Expected overlapping index patterns to be kept when merging duplicates only")
+	}
+
+	c.Merge = "all"
+	result = c.Run(context.Background())
+	if result.Failed() || result.Counts[store.ActionDeleted] != 2 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if _, ok := api.indexPatterns["api-prod"]; ok {
+		t.Errorf("Expected overlapping index pattern to be deleted")
+	}
+	if reference := api.objects["vis"].References[0]; reference.ID != "logs" {
+		t.Errorf("Expected visualization to reference survivor, got %s", reference.ID)
+	}
+}
+
+func TestMergeMissingSurvivor(t *testing.T) {
+	api := newMockAPI()
+	c := NewConsolidateIndexPattern(config.ConsolidateIndexPattern{Merge: "all", ReferenceTypes: []string{"visualization"}}, api, log.Default())
+
+	err := c.merge(context.Background(), kibana.IndexPattern{ID: "gone", Title: "logs-*"}, api.indexPatterns["api-prod"], "")
+	if err == nil {
+		t.Fatalf("Expected merging into a missing survivor to fail")
+	}
+	if _, ok := api.indexPatterns["api-prod"]; !ok || api.objects["vis"].References[0].ID != "api-prod" {
+		t.Errorf("Expected index pattern and its references to be kept")
+	}
+}
+
+func TestMergeIncompleteReferences(t *testing.T) {
+	api := newMockAPI()
+	api.findErr = errors.New("failed to find saved objects, got 999 of 1000")
+	c := NewConsolidateIndexPattern(config.ConsolidateIndexPattern{Merge: "all", ReferenceTypes: []string{"visualization"}}, api, log.Default())
+
+	err := c.merge(context.Background(), api.indexPatterns["logs"], api.indexPatterns["api-prod"], "")
+	if err == nil {
+		t.Fatalf("Expected merging with an incomplete listing of references to fail")
+	}
+	if _, ok := api.indexPatterns["api-prod"]; !ok || api.objects["vis"].References[0].ID != "api-prod" {
+		t.Errorf("Expected index pattern and its references to be kept")
+	}
+}
@@ -0,0 +1,70 @@
+package consolidateindexpattern
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sherifabdlnaby/rubban/rubban/store"
+)
+
+//Run Run Consolidate Index Pattern task
+func (c *ConsolidateIndexPattern) Run(ctx context.Context) store.Result {
+	result := store.NewResult()
+
+	// 1- Find Duplicate and Overlapping Index Patterns
+	defaultID, err := c.kibana.DefaultIndexPattern(ctx)
+	if err != nil {
+		c.log.Errorw("Failed to get default index pattern", "error", err.Error())
+		result.AddError(err)
+		return result
+	}
+
+	groups, err := c.findGroups(ctx, defaultID)
+	if err != nil {
+		c.log.Errorw("Failed to find duplicate and overlapping index patterns", "error", err.Error())
+		result.AddError(err)
+		return result
+	}
+
+	for _, group := range groups {
+		others := make([]string, 0, len(group.Others))
+		for _, other := range group.Others {
+			others = append(others, fmt.Sprintf("%s (%s)", other.Title, other.ID))
+		}
+		if group.Kind == KindDuplicate {
+			result.Add("duplicates", len(group.Others))
+			c.log.Warnw("Found duplicate index patterns", "title", group.Survivor.Title, "survivor", group.Survivor.ID, "duplicates", others)
+		} else {
+			result.Add("overlapping", len(group.Others))
+			c.log.Warnw("Found overlapping index patterns", "title", group.Survivor.Title, "survivor", group.Survivor.ID, "overlapping", others)
+		}
+	}
+
+	// 2- Merge into Survivors
+	for _, group := range groups {
+		if c.Merge == "none" || (c.Merge == "duplicates" && group.Kind != KindDuplicate) {
+			continue
+		}
+
+		for _, other := range group.Others {
+			err := c.merge(ctx, group.Survivor, other, defaultID)
+			if err != nil {
+				c.log.Errorw("Failed to merge index pattern", "title", other.Title, "id", other.ID, "survivor", group.Survivor.ID, "error", err.Error())
+				result.AddError(err)
+				continue
+			}
+			if defaultID == other.ID {
+				defaultID = group.Survivor.ID
+			}
+			result.Add(store.ActionDeleted, 1)
+			c.log.Infow("Merged index pattern", "title", other.Title, "id", other.ID, "survivor", group.Survivor.ID)
+		}
+	}
+
+	return result
+}
+
+//Name Return Task Name
+func (c *ConsolidateIndexPattern) Name() string {
+	return c.name
+}
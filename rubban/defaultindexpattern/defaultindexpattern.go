@@ -0,0 +1,118 @@
+package defaultindexpattern
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/sherifabdlnaby/rubban/config"
+	"github.com/sherifabdlnaby/rubban/log"
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+	"github.com/sherifabdlnaby/rubban/rubban/store"
+)
+
+//DefaultIndexPattern hold attributes for a DefaultIndexPattern loaded from config.
+type DefaultIndexPattern struct {
+	name          string
+	Title         string
+	ID            string
+	TimeFieldName string
+	kibana        kibana.API
+	log           log.Logger
+}
+
+//NewDefaultIndexPattern Constructor
+func NewDefaultIndexPattern(config config.DefaultIndexPattern, kibana kibana.API, log log.Logger) *DefaultIndexPattern {
+	return &DefaultIndexPattern{
+		name:          "Default Index Pattern",
+		Title:         config.Title,
+		ID:            config.ID,
+		TimeFieldName: config.TimeFieldName,
+		kibana:        kibana,
+		log:           log,
+	}
+}
+
+// getIndexPatternID return the ID of the configured index pattern, creating it if it doesn't exist.
+func (d *DefaultIndexPattern) getIndexPatternID(ctx context.Context) (string, error) {
+	if d.ID != "" {
+		_, found, err := d.kibana.GetIndexPattern(ctx, d.ID)
+		if err != nil {
+			return "", err
+		}
+
+		if found {
+			return d.ID, nil
+		}
+
+		if d.Title == "" {
+			return "", fmt.Errorf("index pattern with id [%s] doesn't exist, and no title is configured to create it", d.ID)
+		}
+
+		return d.ID, d.create(ctx)
+	}
+
+	id, err := d.findByTitle(ctx)
+	if err != nil || id != "" {
+		return id, err
+	}
+
+	err = d.create(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// Kibana generated the ID of the created index pattern, so look it up again.
+	id, err = d.findByTitle(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if id == "" {
+		return "", fmt.Errorf("couldn't find index pattern [%s] after creating it", d.Title)
+	}
+
+	return id, nil
+}
+
+// findByTitle return the ID of the index pattern with the exact configured title, empty if it doesn't exist. If many
+// index patterns have the title, the one with the smallest ID is returned so every run picks the same one.
+func (d *DefaultIndexPattern) findByTitle(ctx context.Context) (string, error) {
+	indexPatterns, err := d.kibana.IndexPatterns(ctx, d.Title, nil)
+	if err != nil {
+		return "", err
+	}
+
+	ids := make([]string, 0, 1)
+	for _, indexPattern := range indexPatterns {
+		if indexPattern.Title == d.Title {
+			ids = append(ids, indexPattern.ID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return "", nil
+	}
+
+	sort.Strings(ids)
+	if len(ids) > 1 {
+		d.log.Warnw("Found many index patterns with the default index pattern title, using the one with the smallest ID",
+			"title", d.Title, "ids", ids)
+	}
+	return ids[0], nil
+}
+
+func (d *DefaultIndexPattern) create(ctx context.Context) error {
+	err := d.kibana.BulkCreateIndexPattern(ctx, []kibana.IndexPattern{{
+		ID:            d.ID,
+		Title:         d.Title,
+		TimeFieldName: d.TimeFieldName,
+	}})
+	if err != nil {
+		return err
+	}
+
+	store.Record(ctx, store.Change{Action: store.ActionCreated, Type: "index-pattern", ID: d.ID, Title: d.Title})
+	d.log.Infow("Created default index pattern", "title", d.Title, "id", d.ID)
+	return nil
+}
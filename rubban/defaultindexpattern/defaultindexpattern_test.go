@@ -0,0 +1,141 @@
+package defaultindexpattern
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/sherifabdlnaby/rubban/config"
+	"github.com/sherifabdlnaby/rubban/log"
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+	"github.com/sherifabdlnaby/rubban/rubban/store"
+	"github.com/sherifabdlnaby/rubban/rubban/utils"
+)
+
+type mockAPI struct {
+	kibana.API
+	indexPatterns []kibana.IndexPattern
+	defaultID     string
+	err           error
+}
+
+func (m *mockAPI) IndexPatterns(ctx context.Context, filter string, fields []string) ([]kibana.IndexPattern, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	indexPatterns := make([]kibana.IndexPattern, 0)
+	for _, indexPattern := range m.indexPatterns {
+		if utils.MatchGlob(filter, indexPattern.Title) {
+			indexPatterns = append(indexPatterns, indexPattern)
+		}
+	}
+	return indexPatterns, nil
+}
+
+func (m *mockAPI) GetIndexPattern(ctx context.Context, id string) (kibana.IndexPattern, bool, error) {
+	if m.err != nil {
+		return kibana.IndexPattern{}, false, m.err
+	}
+	for _, indexPattern := range m.indexPatterns {
+		if indexPattern.ID == id {
+			return indexPattern, true, nil
+		}
+	}
+	return kibana.IndexPattern{}, false, nil
+}
+
+func (m *mockAPI) BulkCreateIndexPattern(ctx context.Context, indexPatterns []kibana.IndexPattern) error {
+	for _, indexPattern := range indexPatterns {
+		// Kibana generates an ID if none is set
+		if indexPattern.ID == "" {
+			indexPattern.ID = fmt.Sprintf("generated-%d", len(m.indexPatterns))
+		}
+		m.indexPatterns = append(m.indexPatterns, indexPattern)
+	}
+	return nil
+}
+
+func (m *mockAPI) DefaultIndexPattern(ctx context.Context) (string, error) {
+	return m.defaultID, nil
+}
+
+func (m *mockAPI) SetDefaultIndexPattern(ctx context.Context, id string) error {
+	m.defaultID = id
+	return nil
+}
+
+var indexPatterns = []kibana.IndexPattern{
+	{ID: "logs", Title: "logs-*"},
+	{ID: "logs-api", Title: "logs-api-*"},
+	{ID: "metrics-b", Title: "metrics-*"},
+	{ID: "metrics-a", Title: "metrics-*"},
+}
+
+func TestFindByTitle(t *testing.T) {
+	for _, tcase := range []struct {
+		name     string
+		title    string
+		err      error
+		expected string
+		fails    bool
+	}{
+		{name: "exact title", title: "logs-*", expected: "logs"},
+		{name: "title matching other titles as a pattern", title: "logs-api-*", expected: "logs-api"},
+		{name: "missing title", title: "traces-*", expected: ""},
+		{name: "multiple matches use smallest id", title: "metrics-*", expected: "metrics-a"},
+		{name: "listing fails", title: "logs-*", err: errors.New("500 Internal Server Error"), fails: true},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			d := NewDefaultIndexPattern(config.DefaultIndexPattern{Title: tcase.title}, &mockAPI{indexPatterns: indexPatterns, err: tcase.err}, log.Default())
+
+			id, err := d.findByTitle(context.Background())
+			if (err != nil) != tcase.fails {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if id != tcase.expected {
+				t.Errorf("expected id [%s], got [%s]", tcase.expected, id)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	for _, tcase := range []struct {
+		name      string
+		config    config.DefaultIndexPattern
+		defaultID string
+		expected  string
+		created   bool
+		updated   int
+		fails     bool
+	}{
+		{name: "by title", config: config.DefaultIndexPattern{Title: "logs-*"}, expected: "logs", updated: 1},
+		{name: "already set", config: config.DefaultIndexPattern{Title: "logs-*"}, defaultID: "logs", expected: "logs"},
+		{name: "by id", config: config.DefaultIndexPattern{ID: "logs-api"}, expected: "logs-api", updated: 1},
+		{name: "missing title is created", config: config.DefaultIndexPattern{Title: "traces-*", TimeFieldName: "@timestamp"},
+			expected: "generated-4", created: true, updated: 1},
+		{name: "missing id is created with title", config: config.DefaultIndexPattern{ID: "traces", Title: "traces-*"},
+			expected: "traces", created: true, updated: 1},
+		{name: "missing id without title", config: config.DefaultIndexPattern{ID: "traces"}, defaultID: "logs", expected: "logs", fails: true},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			api := &mockAPI{indexPatterns: append([]kibana.IndexPattern{}, indexPatterns...), defaultID: tcase.defaultID}
+			d := NewDefaultIndexPattern(tcase.config, api, log.Default())
+
+			result := d.Run(context.Background())
+			if result.Failed() != tcase.fails {
+				t.Fatalf("unexpected result %+v", result)
+			}
+			if api.defaultID != tcase.expected {
+				t.Errorf("expected default index pattern [%s], got [%s]", tcase.expected, api.defaultID)
+			}
+			if created := len(api.indexPatterns) > len(indexPatterns); created != tcase.created {
+				t.Errorf("expected index pattern to be created: %t", tcase.created)
+			}
+			if result.Counts[store.ActionUpdated] != tcase.updated {
+				t.Errorf("expected %d update(s), got %+v", tcase.updated, result.Counts)
+			}
+		})
+	}
+}
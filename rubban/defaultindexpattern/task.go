@@ -0,0 +1,51 @@
+package defaultindexpattern
+
+import (
+	"context"
+
+	"github.com/sherifabdlnaby/rubban/rubban/store"
+)
+
+//Run Run Default Index Pattern task
+func (d *DefaultIndexPattern) Run(ctx context.Context) store.Result {
+	result := store.NewResult()
+
+	// 1- Get (or Create) The Configured Index Pattern
+	id, err := d.getIndexPatternID(ctx)
+	if err != nil {
+		d.log.Errorw("Failed to get default index pattern", "title", d.Title, "id", d.ID, "error", err.Error())
+		result.AddError(err)
+		return result
+	}
+
+	// 2- Point Kibana's Default Index Pattern to it
+	current, err := d.kibana.DefaultIndexPattern(ctx)
+	if err != nil {
+		d.log.Errorw("Failed to get current default index pattern", "error", err.Error())
+		result.AddError(err)
+		return result
+	}
+
+	if current == id {
+		d.log.Debugw("Default index pattern is already set", "id", id)
+		return result
+	}
+
+	err = d.kibana.SetDefaultIndexPattern(ctx, id)
+	if err != nil {
+		d.log.Errorw("Failed to set default index pattern", "id", id, "error", err.Error())
+		result.AddError(err)
+		return result
+	}
+
+	store.Record(ctx, store.Change{Action: store.ActionUpdated, Type: "config", ID: "defaultIndex", Title: id})
+	result.Add(store.ActionUpdated, 1)
+
+	d.log.Infow("Successfully set default index pattern", "id", id, "previous", current)
+	return result
+}
+
+//Name Return Task Name
+func (d *DefaultIndexPattern) Name() string {
+	return d.name
+}
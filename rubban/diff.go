@@ -0,0 +1,219 @@
+package rubban
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sherifabdlnaby/rubban/rubban/store"
+	"github.com/sherifabdlnaby/rubban/rubban/utils"
+)
+
+//Kinds of a Drift
+const (
+	DriftMissing    = "missing"
+	DriftUnexpected = "unexpected"
+	DriftTimeField  = "timeField"
+	DriftDuplicate  = "duplicate"
+	DriftChanged    = "changed"
+)
+
+//Exit codes of the diff command
+const (
+	DiffNoDrift = 0
+	DiffDrift   = 1
+	DiffError   = 2
+)
+
+//Drift between what config says should exist and Kibana's index patterns.
+type Drift struct {
+	Kind     string   `json:"kind"`
+	Title    string   `json:"title"`
+	IDs      []string `json:"ids,omitempty"`
+	Source   string   `json:"source,omitempty"`
+	Expected string   `json:"expected,omitempty"`
+	Actual   string   `json:"actual,omitempty"`
+}
+
+//Diff Compare config with Kibana's index patterns, print drift to out in the given format (text or json), and
+//return the exit code of the diff command.
+func Diff(out io.Writer, format string) int {
+	r, s, close, err := newCommand()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to compare config with Kibana, %s\n", err.Error())
+		return DiffError
+	}
+	defer close()
+
+	drifts, err := r.diff(r.mainCtx, s)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to compare config with Kibana: %s\n", err.Error())
+		return DiffError
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(drifts)
+	} else {
+		err = writeDrifts(out, drifts)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write drift: %s\n", err.Error())
+		return DiffError
+	}
+
+	if len(drifts) > 0 {
+		return DiffDrift
+	}
+	return DiffNoDrift
+}
+
+// diff Compare enabled tasks' config with Kibana's index patterns, without changing anything.
+func (r *Rubban) diff(ctx context.Context, s *state) ([]Drift, error) {
+	indexPatterns, err := s.api.IndexPatterns(ctx, "*", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get index patterns, error: %s", err.Error())
+	}
+	sort.Slice(indexPatterns, func(i, j int) bool { return indexPatterns[i].Title < indexPatterns[j].Title })
+
+	drifts := make([]Drift, 0)
+	expected := make(map[string]bool)
+
+	// 1- Auto Index Pattern: Missing index patterns, and index patterns with a wrong time field
+	if s.config.AutoIndexPattern.Enabled {
+		missing, err := s.autoIndexPattern.Missing(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, generalPattern := range s.config.AutoIndexPattern.GeneralPatterns {
+			for _, indexPattern := range missing[generalPattern.Pattern] {
+				drifts = append(drifts, Drift{Kind: DriftMissing, Title: indexPattern.Title, IDs: ids(indexPattern.ID), Source: "autoIndexPattern " + generalPattern.Pattern})
+			}
+		}
+
+		wrongTimeFields, err := s.autoIndexPattern.WrongTimeFields(ctx, indexPatterns)
+		if err != nil {
+			return nil, err
+		}
+		for _, drift := range wrongTimeFields {
+			drifts = append(drifts, Drift{Kind: DriftTimeField, Title: drift.Title, IDs: ids(drift.ID), Source: "autoIndexPattern " + drift.GeneralPattern,
+				Expected: drift.Expected, Actual: drift.TimeFieldName})
+		}
+
+		for _, indexPattern := range indexPatterns {
+			if _, ok := s.autoIndexPattern.GeneralPatternOf(indexPattern.Title); ok {
+				expected[indexPattern.ID] = true
+			}
+		}
+	}
+
+	// 2- Refresh Index Pattern: Patterns that no index pattern matches
+	if s.config.RefreshIndexPattern.Enabled {
+		for _, pattern := range s.config.RefreshIndexPattern.Patterns {
+			found := false
+			for _, indexPattern := range indexPatterns {
+				if utils.MatchGlob(pattern, indexPattern.Title) {
+					expected[indexPattern.ID] = true
+					found = true
+				}
+			}
+			if !found {
+				drifts = append(drifts, Drift{Kind: DriftMissing, Title: pattern, Source: "refreshIndexPattern"})
+			}
+		}
+	}
+
+	// 3- Default Index Pattern
+	if s.config.DefaultIndexPattern.Enabled {
+		defaultIndexPattern := s.config.DefaultIndexPattern
+		found := false
+		for _, indexPattern := range indexPatterns {
+			if (defaultIndexPattern.ID != "" && indexPattern.ID == defaultIndexPattern.ID) ||
+				(defaultIndexPattern.ID == "" && indexPattern.Title == defaultIndexPattern.Title) {
+				expected[indexPattern.ID] = true
+				found = true
+			}
+		}
+		if !found {
+			drifts = append(drifts, Drift{Kind: DriftMissing, Title: defaultIndexPattern.Title, IDs: ids(defaultIndexPattern.ID), Source: "defaultIndexPattern"})
+		}
+	}
+
+	// 4- GitOps: Declared index patterns that are missing or changed
+	if s.config.GitOps.Enabled {
+		plan, err := s.gitOps.Plan(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, change := range plan.Changes {
+			switch change.Action {
+			case store.ActionCreated:
+				drifts = append(drifts, Drift{Kind: DriftMissing, Title: change.Desired.Title, IDs: ids(change.Desired.ID), Source: "gitOps " + change.Source})
+			case store.ActionUpdated:
+				drifts = append(drifts, Drift{Kind: DriftChanged, Title: change.Desired.Title, IDs: ids(change.Desired.ID), Source: "gitOps " + change.Source})
+			}
+		}
+
+		for _, id := range plan.Declared() {
+			expected[id] = true
+		}
+	}
+
+	// 5- Index Patterns no enabled task expects, and index patterns with the same title
+	titles := make(map[string][]string)
+	for _, indexPattern := range indexPatterns {
+		titles[indexPattern.Title] = append(titles[indexPattern.Title], indexPattern.ID)
+		if !expected[indexPattern.ID] {
+			drifts = append(drifts, Drift{Kind: DriftUnexpected, Title: indexPattern.Title, IDs: ids(indexPattern.ID)})
+		}
+	}
+
+	reported := make(map[string]bool)
+	for _, indexPattern := range indexPatterns {
+		duplicates := titles[indexPattern.Title]
+		if len(duplicates) > 1 && !reported[indexPattern.Title] {
+			reported[indexPattern.Title] = true
+			sort.Strings(duplicates)
+			drifts = append(drifts, Drift{Kind: DriftDuplicate, Title: indexPattern.Title, IDs: duplicates})
+		}
+	}
+
+	return drifts, nil
+}
+
+func ids(id string) []string {
+	if id == "" {
+		return nil
+	}
+	return []string{id}
+}
+
+// writeDrifts write drifts as a table.
+func writeDrifts(out io.Writer, drifts []Drift) error {
+	if len(drifts) == 0 {
+		_, err := fmt.Fprintln(out, "No drift found.")
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tTITLE\tIDS\tSOURCE\tDETAILS")
+	for _, drift := range drifts {
+		details := ""
+		if drift.Kind == DriftTimeField {
+			details = fmt.Sprintf("expected %q, got %q", drift.Expected, drift.Actual)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", drift.Kind, drift.Title, strings.Join(drift.IDs, ","), drift.Source, details)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(out, "\nFound %d drift(s).\n", len(drifts))
+	return err
+}
@@ -0,0 +1,62 @@
+package rubban
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sherifabdlnaby/rubban/config"
+	"github.com/sherifabdlnaby/rubban/log"
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+)
+
+type mockAPI struct {
+	kibana.API
+	indices       []kibana.Index
+	indexPatterns []kibana.IndexPattern
+}
+
+func (m *mockAPI) Indices(ctx context.Context, filter string) ([]kibana.Index, error) {
+	return m.indices, nil
+}
+
+func (m *mockAPI) IndexPatterns(ctx context.Context, filter string, fields []string) ([]kibana.IndexPattern, error) {
+	return m.indexPatterns, nil
+}
+
+func TestDiff(t *testing.T) {
+	cfg := config.Default()
+	cfg.AutoIndexPattern.Enabled = true
+	cfg.AutoIndexPattern.GeneralPatterns = []config.GeneralPattern{{Pattern: "logs-?-*", TimeFieldName: "@timestamp"}}
+	cfg.RefreshIndexPattern.Enabled = true
+	cfg.RefreshIndexPattern.Patterns = []string{"metrics-*"}
+
+	s := &state{config: cfg, api: &mockAPI{
+		indices: []kibana.Index{{Name: "logs-api-2020.02.14"}, {Name: "logs-web-2020.02.14"}},
+		indexPatterns: []kibana.IndexPattern{
+			{ID: "api", Title: "logs-api-*", TimeFieldName: "timestamp"},
+			{ID: "api-copy", Title: "logs-api-*", TimeFieldName: "@timestamp"},
+			{ID: "manual", Title: "manual-*"},
+		},
+	}}
+
+	r := New()
+	r.initTasks(s, log.Nop())
+
+	drifts, err := r.diff(context.Background(), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Drift{
+		{Kind: DriftMissing, Title: "logs-web-*", Source: "autoIndexPattern logs-?-*"},
+		{Kind: DriftTimeField, Title: "logs-api-*", IDs: []string{"api"}, Source: "autoIndexPattern logs-?-*", Expected: "@timestamp", Actual: "timestamp"},
+		{Kind: DriftMissing, Title: "metrics-*", Source: "refreshIndexPattern"},
+		{Kind: DriftUnexpected, Title: "manual-*", IDs: []string{"manual"}},
+		{Kind: DriftDuplicate, Title: "logs-api-*", IDs: []string{"api", "api-copy"}},
+	}
+
+	if !reflect.DeepEqual(drifts, expected) {
+		t.Errorf("Expected drifts\n%+v\ngot\n%+v", expected, drifts)
+	}
+}
@@ -0,0 +1,146 @@
+package dryrun
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"sync"
+
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+	"github.com/sherifabdlnaby/rubban/rubban/utils"
+)
+
+//GeneratedID is the ID of index patterns created without an ID during a dry-run (Kibana would have generated one).
+const GeneratedID = "(generated)"
+
+//API wraps a kibana.API, it reads from Kibana but discards every change made through it. Tasks running with it record
+//the changes they would have made, and index patterns they would have created (or deleted) are visible to later reads.
+type API struct {
+	kibana.API
+	created []kibana.IndexPattern
+	deleted map[string]bool
+	mx      sync.Mutex
+}
+
+//NewAPI Constructor
+func NewAPI(api kibana.API) *API {
+	return &API{API: api, deleted: make(map[string]bool)}
+}
+
+//IndexPatterns Get Index Patterns matching filter, including ones created during the dry-run.
+func (a *API) IndexPatterns(ctx context.Context, filter string, fields []string) ([]kibana.IndexPattern, error) {
+	indexPatterns, err := a.API.IndexPatterns(ctx, filter, fields)
+	if err != nil {
+		return indexPatterns, err
+	}
+
+	regex := regexp.MustCompile(utils.PatternToRegex(filter))
+
+	a.mx.Lock()
+	defer a.mx.Unlock()
+
+	// Hide index patterns deleted or overwritten during the dry-run
+	overlaid := make(map[string]bool, len(a.deleted)+len(a.created))
+	for id := range a.deleted {
+		overlaid[id] = true
+	}
+	for _, indexPattern := range a.created {
+		overlaid[indexPattern.ID] = true
+	}
+
+	visible := make([]kibana.IndexPattern, 0, len(indexPatterns))
+	for _, indexPattern := range indexPatterns {
+		if !overlaid[indexPattern.ID] {
+			visible = append(visible, indexPattern)
+		}
+	}
+	indexPatterns = visible
+
+	for _, indexPattern := range a.created {
+		if regex.MatchString(indexPattern.Title) {
+			indexPatterns = append(indexPatterns, indexPattern)
+		}
+	}
+	return indexPatterns, nil
+}
+
+//GetIndexPattern Get IndexPattern by ID, including ones created during the dry-run.
+func (a *API) GetIndexPattern(ctx context.Context, id string) (kibana.IndexPattern, bool, error) {
+	a.mx.Lock()
+	for i := len(a.created) - 1; i >= 0; i-- {
+		if a.created[i].ID == id {
+			a.mx.Unlock()
+			return a.created[i], true, nil
+		}
+	}
+	deleted := a.deleted[id]
+	a.mx.Unlock()
+
+	if deleted {
+		return kibana.IndexPattern{}, false, nil
+	}
+	return a.API.GetIndexPattern(ctx, id)
+}
+
+//BulkCreateIndexPattern Discard index patterns (keeping them for later reads)
+func (a *API) BulkCreateIndexPattern(ctx context.Context, indexPatterns []kibana.IndexPattern) error {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	for _, indexPattern := range indexPatterns {
+		if indexPattern.ID == "" {
+			indexPattern.ID = GeneratedID
+		}
+		a.created = append(a.created, indexPattern)
+		delete(a.deleted, indexPattern.ID)
+	}
+	return nil
+}
+
+//DeleteIndexPattern Discard deletion (hiding the index pattern from later reads)
+func (a *API) DeleteIndexPattern(ctx context.Context, id string) error {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	created := make([]kibana.IndexPattern, 0, len(a.created))
+	for _, indexPattern := range a.created {
+		if indexPattern.ID != id {
+			created = append(created, indexPattern)
+		}
+	}
+	a.created = created
+	a.deleted[id] = true
+	return nil
+}
+
+//UpdateSavedObject Discard saved object update
+func (a *API) UpdateSavedObject(ctx context.Context, object kibana.SavedObject) error {
+	return nil
+}
+
+//ImportSavedObjects Discard import (reporting every saved object as imported)
+func (a *API) ImportSavedObjects(ctx context.Context, ndjson []byte, overwrite bool) (kibana.ImportResult, error) {
+	objects, err := kibana.DecodeSavedObjects(ndjson)
+	if err != nil {
+		return kibana.ImportResult{}, err
+	}
+	return kibana.ImportResult{Success: true, SuccessCount: len(objects)}, nil
+}
+
+//CreateRule Discard rule (reporting it as created)
+func (a *API) CreateRule(ctx context.Context, id string, rule json.RawMessage) (bool, error) {
+	return true, nil
+}
+
+//DeleteRule Discard rule deletion
+func (a *API) DeleteRule(ctx context.Context, id string) error {
+	return nil
+}
+
+//SetDefaultIndexPattern Discard default index pattern
+func (a *API) SetDefaultIndexPattern(ctx context.Context, id string) error {
+	return nil
+}
+
+//IndexDocument Discard document
+func (a *API) IndexDocument(ctx context.Context, index, id string, doc interface{}, version *kibana.DocumentVersion) error {
+	return nil
+}
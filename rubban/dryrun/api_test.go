@@ -0,0 +1,58 @@
+package dryrun
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+)
+
+type mockAPI struct {
+	kibana.API
+	indexPatterns []kibana.IndexPattern
+}
+
+func (m *mockAPI) IndexPatterns(ctx context.Context, filter string, fields []string) ([]kibana.IndexPattern, error) {
+	return m.indexPatterns, nil
+}
+
+func (m *mockAPI) GetIndexPattern(ctx context.Context, id string) (kibana.IndexPattern, bool, error) {
+	return kibana.IndexPattern{}, false, nil
+}
+
+func (m *mockAPI) BulkCreateIndexPattern(ctx context.Context, indexPatterns []kibana.IndexPattern) error {
+	panic("dry-run must not create index patterns")
+}
+
+func TestDryRunAPI(t *testing.T) {
+	ctx := context.Background()
+	api := NewAPI(&mockAPI{indexPatterns: []kibana.IndexPattern{{ID: "logs", Title: "logs-*"}}})
+
+	err := api.BulkCreateIndexPattern(ctx, []kibana.IndexPattern{{ID: "metrics", Title: "metrics-*"}, {Title: "metrics-api-*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	indexPatterns, err := api.IndexPatterns(ctx, "metrics-api-*", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(indexPatterns) != 2 || indexPatterns[1].ID != GeneratedID {
+		t.Errorf("Expected created index pattern with a generated ID, got %v", indexPatterns)
+	}
+
+	_, found, err := api.GetIndexPattern(ctx, "metrics")
+	if err != nil || !found {
+		t.Errorf("Expected created index pattern to be found, got %v, %v", found, err)
+	}
+
+	err = api.DeleteIndexPattern(ctx, "logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	indexPatterns, _ = api.IndexPatterns(ctx, "logs-*", nil)
+	if len(indexPatterns) != 0 {
+		t.Errorf("Expected deleted index pattern to be hidden, got %v", indexPatterns)
+	}
+}
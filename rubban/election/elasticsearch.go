@@ -0,0 +1,73 @@
+package election
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sherifabdlnaby/rubban/config"
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+)
+
+//elasticsearchLock is a Lock stored as a document in Elasticsearch (through Kibana's API), acquiring and renewing
+//uses optimistic concurrency control so only one replica can win.
+type elasticsearchLock struct {
+	index string
+	id    string
+	api   kibana.API
+}
+
+type lockDocument struct {
+	Holder        string    `json:"holder"`
+	RenewTime     time.Time `json:"renewTime"`
+	LeaseDuration int64     `json:"leaseDurationSeconds"`
+}
+
+func newElasticsearchLock(config config.LeaderElectionElasticsearch, api kibana.API) *elasticsearchLock {
+	return &elasticsearchLock{index: config.Index, id: config.ID, api: api}
+}
+
+func (e *elasticsearchLock) TryAcquire(ctx context.Context, identity string, leaseDuration time.Duration) (bool, error) {
+	current := lockDocument{}
+	version, err := e.api.GetDocument(ctx, e.index, e.id, &current)
+	if err != nil {
+		return false, err
+	}
+
+	if version != nil && current.Holder != identity && current.Holder != "" &&
+		time.Since(current.RenewTime) < time.Duration(current.LeaseDuration)*time.Second {
+		return false, nil
+	}
+
+	err = e.api.IndexDocument(ctx, e.index, e.id, lockDocument{
+		Holder:        identity,
+		RenewTime:     time.Now().UTC(),
+		LeaseDuration: int64(leaseDuration.Seconds()),
+	}, version)
+	if err == kibana.ErrConflict {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (e *elasticsearchLock) Release(ctx context.Context, identity string) error {
+	current := lockDocument{}
+	version, err := e.api.GetDocument(ctx, e.index, e.id, &current)
+	if err != nil || version == nil || current.Holder != identity {
+		return err
+	}
+
+	err = e.api.IndexDocument(ctx, e.index, e.id, lockDocument{RenewTime: time.Now().UTC()}, version)
+	if err == kibana.ErrConflict {
+		return nil
+	}
+	return err
+}
+
+func (e *elasticsearchLock) String() string {
+	return fmt.Sprintf("elasticsearch lock document [%s/%s]", e.index, e.id)
+}
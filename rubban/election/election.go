@@ -0,0 +1,148 @@
+package election
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sherifabdlnaby/rubban/config"
+	"github.com/sherifabdlnaby/rubban/log"
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+	"go.uber.org/atomic"
+)
+
+//Lock is a lease based lock that can only be held by a single identity at a time.
+type Lock interface {
+	// TryAcquire Acquire the lock (or renew it if already held) for the given lease duration, returns true if identity holds the lock.
+	TryAcquire(ctx context.Context, identity string, leaseDuration time.Duration) (bool, error)
+
+	// Release Release the lock if it's held by identity.
+	Release(ctx context.Context, identity string) error
+
+	// String Describe the lock (for logging)
+	String() string
+}
+
+//Elector elects a single leader between Rubban replicas sharing the same Lock.
+type Elector struct {
+	lock          Lock
+	identity      string
+	leaseDuration time.Duration
+	renewDeadline time.Duration
+	retryPeriod   time.Duration
+	leader        atomic.Bool
+	// lastRenew is when the last successful acquire or renew request started (unix nano), the lease can't expire
+	// before lastRenew + leaseDuration.
+	lastRenew atomic.Int64
+	logger    log.Logger
+}
+
+//NewElector Constructor
+func NewElector(config config.LeaderElection, api kibana.API, logger log.Logger) (*Elector, error) {
+	var lock Lock
+	var err error
+
+	switch config.Backend {
+	case "file":
+		lock = newFileLock(config.File.Path)
+	case "kubernetes":
+		lock, err = newKubernetesLock(config.Kubernetes)
+	case "elasticsearch":
+		lock = newElasticsearchLock(config.Elasticsearch, api)
+	default:
+		err = fmt.Errorf("unknown leader election backend [%s]", config.Backend)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	identity := config.Identity
+	if identity == "" {
+		identity, err = os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get hostname to use as identity, error: %s", err.Error())
+		}
+	}
+
+	return &Elector{
+		lock:          lock,
+		identity:      identity,
+		leaseDuration: config.LeaseDuration,
+		renewDeadline: config.RenewDeadline,
+		retryPeriod:   config.RetryPeriod,
+		logger:        logger,
+	}, nil
+}
+
+//IsLeader Return true if this replica is currently the leader, a leader that couldn't renew its lease within the renew
+//deadline isn't (even while a renew request is still pending).
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load() && time.Since(time.Unix(0, e.lastRenew.Load())) < e.renewDeadline
+}
+
+//Run Try to acquire leadership and keep renewing it every retry period, blocks until context is canceled, then
+//releases leadership if held.
+func (e *Elector) Run(ctx context.Context) {
+	e.logger.Infof("Starting leader election as [%s] using %s", e.identity, e.lock)
+
+	ticker := time.NewTicker(e.retryPeriod)
+	defer ticker.Stop()
+
+	for {
+		e.renew(ctx)
+
+		select {
+		case <-ctx.Done():
+			e.release()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// renew Try to acquire or renew leadership once.
+func (e *Elector) renew(ctx context.Context) {
+	start := time.Now()
+	acquired, err := e.lock.TryAcquire(ctx, e.identity, e.leaseDuration)
+	switch {
+	case err != nil:
+		e.logger.Warnw("Failed to acquire or renew leadership", "error", err.Error())
+		// Keep leadership until the renew deadline, well before the lease expires and a standby can acquire it.
+		if e.leader.Load() && time.Since(time.Unix(0, e.lastRenew.Load())) >= e.renewDeadline {
+			e.stepDown("failed to renew lease before renew deadline")
+		}
+	case acquired:
+		// The lease is renewed some time after the request started, so starting time is a safe lower bound.
+		e.lastRenew.Store(start.UnixNano())
+		if !e.leader.Swap(true) {
+			e.logger.Infof("[%s] Became the leader, will run tasks.", e.identity)
+		}
+	default:
+		e.stepDown("another replica is the leader")
+	}
+}
+
+func (e *Elector) stepDown(reason string) {
+	if e.leader.Swap(false) {
+		e.logger.Warnf("[%s] Lost leadership (%s), will not run tasks.", e.identity, reason)
+	} else {
+		e.logger.Debugf("[%s] Not the leader (%s)", e.identity, reason)
+	}
+}
+
+func (e *Elector) release() {
+	if !e.leader.Swap(false) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := e.lock.Release(ctx, e.identity)
+	if err != nil {
+		e.logger.Warnw("Failed to release leadership", "error", err.Error())
+		return
+	}
+	e.logger.Infof("[%s] Released leadership.", e.identity)
+}
@@ -0,0 +1,117 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sherifabdlnaby/rubban/log"
+)
+
+type mockLock struct {
+	holder string
+	err    error
+}
+
+func (m *mockLock) TryAcquire(ctx context.Context, identity string, leaseDuration time.Duration) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	if m.holder == "" {
+		m.holder = identity
+	}
+	return m.holder == identity, nil
+}
+
+func (m *mockLock) Release(ctx context.Context, identity string) error {
+	if m.holder == identity {
+		m.holder = ""
+	}
+	return nil
+}
+
+func (m *mockLock) String() string {
+	return "mock lock"
+}
+
+// TestElectorLeadership tests acquiring, keeping and losing leadership.
+func TestElectorLeadership(t *testing.T) {
+	ctx := context.Background()
+	lock := &mockLock{}
+	elector := &Elector{lock: lock, identity: "a", leaseDuration: time.Minute, renewDeadline: 40 * time.Second,
+		retryPeriod: time.Second, logger: log.Default()}
+
+	elector.renew(ctx)
+	if !elector.IsLeader() {
+		t.Fatalf("expected to acquire leadership")
+	}
+
+	lock.err = errors.New("kibana unreachable")
+	elector.renew(ctx)
+	if !elector.IsLeader() {
+		t.Fatalf("expected to keep leadership before renew deadline")
+	}
+
+	// Last successful renew was 45s ago, the lease (1m) hasn't expired yet but the renew deadline (40s) has.
+	elector.lastRenew.Store(time.Now().Add(-45 * time.Second).UnixNano())
+	if elector.IsLeader() {
+		t.Fatalf("expected not to be leader after renew deadline, even before stepping down")
+	}
+	elector.renew(ctx)
+	if elector.leader.Load() {
+		t.Fatalf("expected to step down after renew deadline, before lease expires")
+	}
+
+	lock.err = nil
+	lock.holder = "b"
+	elector.renew(ctx)
+	if elector.IsLeader() {
+		t.Fatalf("expected not to be leader while another replica holds the lock")
+	}
+
+	lock.holder = ""
+	elector.renew(ctx)
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	elector.Run(canceled)
+	if elector.IsLeader() || lock.holder != "" {
+		t.Fatalf("expected leadership to be released after context is canceled")
+	}
+}
+
+// TestKubernetesLockTokenRotation tests that a rotated service account token is used by following requests.
+func TestKubernetesLockTokenRotation(t *testing.T) {
+	tokens := make([]string, 0)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "serviceaccount")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+
+	lock := &kubernetesLock{host: server.URL, tokenFile: tokenFile, namespace: "default", name: "rubban", http: server.Client()}
+	for _, token := range []string{"first", "rotated"} {
+		if err := ioutil.WriteFile(tokenFile, []byte(token+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := lock.get(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(tokens) != 2 || tokens[0] != "Bearer first" || tokens[1] != "Bearer rotated" {
+		t.Errorf("expected rotated token to be used, got %v", tokens)
+	}
+}
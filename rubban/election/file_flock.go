@@ -0,0 +1,73 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package election
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+//fileLock is a Lock using an exclusive flock(2) on a local file, for replicas running on the same host (or sharing
+//a filesystem that supports flock). The lock is held as long as the process is alive, so lease duration is ignored.
+type fileLock struct {
+	path string
+	file *os.File
+	mx   sync.Mutex
+}
+
+func newFileLock(path string) *fileLock {
+	return &fileLock{path: path}
+}
+
+func (f *fileLock) TryAcquire(ctx context.Context, identity string, leaseDuration time.Duration) (bool, error) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	if f.file != nil {
+		return true, nil
+	}
+
+	file, err := os.OpenFile(f.path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return false, err
+	}
+
+	err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err != nil {
+		_ = file.Close()
+		if err == syscall.EWOULDBLOCK {
+			return false, nil
+		}
+		return false, err
+	}
+
+	// Write holder identity for humans.
+	_ = file.Truncate(0)
+	_, _ = file.WriteAt([]byte(identity+"\n"), 0)
+
+	f.file = file
+	return true, nil
+}
+
+func (f *fileLock) Release(ctx context.Context, identity string) error {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := syscall.Flock(int(f.file.Fd()), syscall.LOCK_UN)
+	_ = f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *fileLock) String() string {
+	return fmt.Sprintf("file lock [%s]", f.path)
+}
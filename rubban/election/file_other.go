@@ -0,0 +1,31 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package election
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+//fileLock is not supported on platforms without flock(2).
+type fileLock struct {
+	path string
+}
+
+func newFileLock(path string) *fileLock {
+	return &fileLock{path: path}
+}
+
+func (f *fileLock) TryAcquire(ctx context.Context, identity string, leaseDuration time.Duration) (bool, error) {
+	return false, fmt.Errorf("file leader election backend is not supported on this platform")
+}
+
+func (f *fileLock) Release(ctx context.Context, identity string) error {
+	return nil
+}
+
+func (f *fileLock) String() string {
+	return fmt.Sprintf("file lock [%s]", f.path)
+}
@@ -0,0 +1,243 @@
+package election
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sherifabdlnaby/rubban/config"
+)
+
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount/"
+
+// microTime is the time format of Kubernetes's MicroTime
+const microTime = "2006-01-02T15:04:05.000000Z07:00"
+
+//kubernetesLock is a Lock using a Kubernetes coordination.k8s.io/v1 Lease, it uses the in-cluster service account,
+//which must be allowed to get, create and update leases in the namespace.
+type kubernetesLock struct {
+	host      string
+	tokenFile string
+	namespace string
+	name      string
+	http      *http.Client
+}
+
+type lease struct {
+	APIVersion string        `json:"apiVersion"`
+	Kind       string        `json:"kind"`
+	Metadata   leaseMetadata `json:"metadata"`
+	Spec       leaseSpec     `json:"spec"`
+}
+
+type leaseMetadata struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+type leaseSpec struct {
+	HolderIdentity       string `json:"holderIdentity"`
+	LeaseDurationSeconds int64  `json:"leaseDurationSeconds"`
+	AcquireTime          string `json:"acquireTime,omitempty"`
+	RenewTime            string `json:"renewTime,omitempty"`
+	LeaseTransitions     int64  `json:"leaseTransitions"`
+}
+
+func newKubernetesLock(config config.LeaderElectionKubernetes) (*kubernetesLock, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("kubernetes leader election backend can only be used in-cluster")
+	}
+
+	tokenFile := serviceAccountDir + "token"
+	if _, err := readToken(tokenFile); err != nil {
+		return nil, err
+	}
+
+	ca, err := ioutil.ReadFile(serviceAccountDir + "ca.crt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account ca, error: %s", err.Error())
+	}
+	certPool := x509.NewCertPool()
+	certPool.AppendCertsFromPEM(ca)
+
+	namespace := config.Namespace
+	if namespace == "" {
+		ns, err := ioutil.ReadFile(serviceAccountDir + "namespace")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read service account namespace, error: %s", err.Error())
+		}
+		namespace = strings.TrimSpace(string(ns))
+	}
+
+	return &kubernetesLock{
+		host:      "https://" + host + ":" + port,
+		tokenFile: tokenFile,
+		namespace: namespace,
+		name:      config.Name,
+		http: &http.Client{
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: certPool}},
+			Timeout:   10 * time.Second,
+		},
+	}, nil
+}
+
+func (k *kubernetesLock) TryAcquire(ctx context.Context, identity string, leaseDuration time.Duration) (bool, error) {
+	now := time.Now().UTC().Format(microTime)
+
+	current, err := k.get(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	// Create Lease if it doesn't exist.
+	if current == nil {
+		created := lease{
+			APIVersion: "coordination.k8s.io/v1",
+			Kind:       "Lease",
+			Metadata:   leaseMetadata{Name: k.name, Namespace: k.namespace},
+			Spec: leaseSpec{
+				HolderIdentity:       identity,
+				LeaseDurationSeconds: int64(leaseDuration.Seconds()),
+				AcquireTime:          now,
+				RenewTime:            now,
+			},
+		}
+		return k.write(ctx, http.MethodPost, k.path(""), created)
+	}
+
+	if current.Spec.HolderIdentity != identity && current.Spec.HolderIdentity != "" {
+		renewTime, err := time.Parse(microTime, current.Spec.RenewTime)
+		if err == nil && time.Since(renewTime) < time.Duration(current.Spec.LeaseDurationSeconds)*time.Second {
+			return false, nil
+		}
+	}
+
+	updated := *current
+	if updated.Spec.HolderIdentity != identity {
+		updated.Spec.AcquireTime = now
+		updated.Spec.LeaseTransitions++
+	}
+	updated.Spec.HolderIdentity = identity
+	updated.Spec.LeaseDurationSeconds = int64(leaseDuration.Seconds())
+	updated.Spec.RenewTime = now
+
+	return k.write(ctx, http.MethodPut, k.path(k.name), updated)
+}
+
+func (k *kubernetesLock) Release(ctx context.Context, identity string) error {
+	current, err := k.get(ctx)
+	if err != nil || current == nil || current.Spec.HolderIdentity != identity {
+		return err
+	}
+
+	released := *current
+	released.Spec.HolderIdentity = ""
+	released.Spec.LeaseDurationSeconds = 1
+	released.Spec.RenewTime = time.Now().UTC().Format(microTime)
+
+	_, err = k.write(ctx, http.MethodPut, k.path(k.name), released)
+	return err
+}
+
+func (k *kubernetesLock) String() string {
+	return fmt.Sprintf("kubernetes lease [%s/%s]", k.namespace, k.name)
+}
+
+func (k *kubernetesLock) path(name string) string {
+	path := fmt.Sprintf("%s/apis/coordination.k8s.io/v1/namespaces/%s/leases", k.host, k.namespace)
+	if name != "" {
+		path += "/" + name
+	}
+	return path
+}
+
+// get Return the Lease, nil if it doesn't exist.
+func (k *kubernetesLock) get(ctx context.Context) (*lease, error) {
+	resp, err := k.do(ctx, http.MethodGet, k.path(k.name), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to get lease, error: %s", resp.Status)
+	}
+
+	current := &lease{}
+	err = json.NewDecoder(resp.Body).Decode(current)
+	if err != nil {
+		return nil, err
+	}
+
+	return current, nil
+}
+
+// write Create or Update the Lease, returns false if it was changed by someone else in between.
+func (k *kubernetesLock) write(ctx context.Context, method, url string, l lease) (bool, error) {
+	buff, err := json.Marshal(l)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := k.do(ctx, method, url, bytes.NewReader(buff))
+	if err != nil {
+		return false, err
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict {
+		return false, nil
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return false, fmt.Errorf("failed to write lease, error: %s", resp.Status)
+	}
+
+	return true, nil
+}
+
+func (k *kubernetesLock) do(ctx context.Context, method, url string, body *bytes.Reader) (*http.Response, error) {
+	var req *http.Request
+	var err error
+	if body != nil {
+		req, err = http.NewRequestWithContext(ctx, method, url, body)
+	} else {
+		req, err = http.NewRequestWithContext(ctx, method, url, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Token is re-read on every request, as Kubernetes rotates projected service account tokens.
+	token, err := readToken(k.tokenFile)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return k.http.Do(req)
+}
+
+func readToken(path string) (string, error) {
+	token, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read service account token, error: %s", err.Error())
+	}
+	return strings.TrimSpace(string(token)), nil
+}
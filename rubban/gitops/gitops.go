@@ -0,0 +1,304 @@
+package gitops
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/sherifabdlnaby/rubban/config"
+	"github.com/sherifabdlnaby/rubban/log"
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+	"github.com/sherifabdlnaby/rubban/rubban/store"
+)
+
+//GitOps hold attributes for a GitOps task loaded from config.
+type GitOps struct {
+	name   string
+	Path   string
+	prune  bool
+	index  string
+	id     string
+	kibana kibana.API
+	log    log.Logger
+}
+
+//NewGitOps Constructor
+func NewGitOps(config config.GitOps, kibana kibana.API, log log.Logger) *GitOps {
+	return &GitOps{
+		name:   "GitOps",
+		Path:   config.Path,
+		prune:  config.Prune,
+		index:  config.Elasticsearch.Index,
+		id:     config.Elasticsearch.ID,
+		kibana: kibana,
+		log:    log,
+	}
+}
+
+//Change to an index pattern needed to match its definition, Current is nil for index patterns to create, and
+//Desired is nil for index patterns to delete.
+type Change struct {
+	Action  string
+	Source  string
+	Current *kibana.IndexPattern
+	Desired *kibana.IndexPattern
+}
+
+//Plan is the changes needed to make Kibana's index patterns match the declared ones.
+type Plan struct {
+	Changes  []Change
+	declared []string
+	existing map[string]bool
+	owned    ownedDocument
+	version  *kibana.DocumentVersion
+}
+
+//Declared Return IDs of declared index patterns.
+func (p *Plan) Declared() []string {
+	return p.declared
+}
+
+// ownedDocument is the Elasticsearch document listing IDs of index patterns Rubban owns, only owned index patterns
+// are pruned.
+type ownedDocument struct {
+	IDs []string `json:"ids"`
+}
+
+//Plan Compare declared index patterns with Kibana's, without changing anything.
+func (g *GitOps) Plan(ctx context.Context) (*Plan, error) {
+	definitions, err := Load(g.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := g.kibana.IndexPatterns(ctx, "*", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get index patterns, error: %s", err.Error())
+	}
+
+	plan := &Plan{existing: make(map[string]bool)}
+	plan.version, err = g.kibana.GetDocument(ctx, g.index, g.id, &plan.owned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get owned index patterns, error: %s", err.Error())
+	}
+
+	currentByID := make(map[string]kibana.IndexPattern, len(current))
+	for _, indexPattern := range current {
+		currentByID[indexPattern.ID] = indexPattern
+		plan.existing[indexPattern.ID] = true
+	}
+
+	sort.Slice(definitions, func(i, j int) bool { return definitions[i].ID < definitions[j].ID })
+
+	declared := make(map[string]bool, len(definitions))
+	for i := range definitions {
+		definition := definitions[i]
+		declared[definition.ID] = true
+		plan.declared = append(plan.declared, definition.ID)
+
+		existing, ok := currentByID[definition.ID]
+		if !ok {
+			plan.Changes = append(plan.Changes, Change{Action: store.ActionCreated, Source: definition.Source, Desired: &definition.IndexPattern})
+			continue
+		}
+
+		if drifted(existing, definition.IndexPattern) {
+			plan.Changes = append(plan.Changes, Change{Action: store.ActionUpdated, Source: definition.Source, Current: &existing, Desired: &definition.IndexPattern})
+		}
+	}
+
+	if g.prune {
+		owned := append([]string{}, plan.owned.IDs...)
+		sort.Strings(owned)
+		for _, id := range owned {
+			existing, ok := currentByID[id]
+			if ok && !declared[id] {
+				plan.Changes = append(plan.Changes, Change{Action: store.ActionDeleted, Current: &existing})
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+//Apply Apply changes of a plan, and record declared index patterns as owned by Rubban once they exist.
+func (g *GitOps) Apply(ctx context.Context, plan *Plan) store.Result {
+	result := store.NewResult()
+
+	// 1- Create Index Patterns
+	creates := make([]kibana.IndexPattern, 0)
+	for _, change := range plan.Changes {
+		if change.Action == store.ActionCreated {
+			creates = append(creates, *change.Desired)
+		}
+	}
+
+	created := make(map[string]bool)
+	if len(creates) > 0 {
+		err := g.kibana.BulkCreateIndexPattern(ctx, creates)
+		if err != nil {
+			g.log.Errorw("Failed to create index patterns", "error", err.Error())
+			result.AddError(err)
+		} else {
+			for _, change := range plan.Changes {
+				if change.Action != store.ActionCreated {
+					continue
+				}
+				created[change.Desired.ID] = true
+				g.synced(ctx, change, &result)
+			}
+		}
+	}
+
+	// 2- Update Drifted Index Patterns, only drifted attributes are updated so attributes that aren't declared (e.g.
+	// fields, custom labels) are kept.
+	for _, change := range plan.Changes {
+		if change.Action != store.ActionUpdated {
+			continue
+		}
+
+		attributes, err := json.Marshal(driftedAttributes(*change.Current, *change.Desired))
+		if err != nil {
+			result.AddError(fmt.Errorf("failed to JSON marshaling index pattern [%s], error: %s", change.Desired.ID, err.Error()))
+			continue
+		}
+
+		err = g.kibana.UpdateSavedObject(ctx, kibana.SavedObject{Type: "index-pattern", ID: change.Desired.ID, Attributes: attributes})
+		if err != nil {
+			g.log.Errorw("Failed to update index pattern", "id", change.Desired.ID, "error", err.Error())
+			result.AddError(err)
+			continue
+		}
+		g.synced(ctx, change, &result)
+	}
+
+	// 3- Prune Owned Index Patterns that are no longer declared
+	deleted := make(map[string]bool)
+	for _, change := range plan.Changes {
+		if change.Action != store.ActionDeleted {
+			continue
+		}
+		err := g.kibana.DeleteIndexPattern(ctx, change.Current.ID)
+		if err != nil {
+			g.log.Errorw("Failed to delete index pattern", "id", change.Current.ID, "error", err.Error())
+			result.AddError(err)
+			continue
+		}
+		deleted[change.Current.ID] = true
+		store.Record(ctx, store.Change{Action: store.ActionDeleted, Type: "index-pattern", ID: change.Current.ID, Title: change.Current.Title})
+		result.Add(store.ActionDeleted, 1)
+		g.log.Infow("Pruned index pattern", "id", change.Current.ID, "title", change.Current.Title)
+	}
+
+	// 4- Record Ownership of declared index patterns that exist (ones that failed to be created aren't owned), previously
+	// owned index patterns stay owned until they're deleted.
+	owned := make([]string, 0, len(plan.declared))
+	isDeclared := make(map[string]bool, len(plan.declared))
+	for _, id := range plan.declared {
+		isDeclared[id] = true
+		if plan.existing[id] || created[id] {
+			owned = append(owned, id)
+		}
+	}
+	for _, id := range plan.owned.IDs {
+		if !isDeclared[id] && plan.existing[id] && !deleted[id] {
+			owned = append(owned, id)
+		}
+	}
+	sort.Strings(owned)
+
+	previous := append([]string{}, plan.owned.IDs...)
+	sort.Strings(previous)
+	if reflect.DeepEqual(owned, previous) || (len(owned) == 0 && len(previous) == 0) {
+		return result
+	}
+
+	err := g.kibana.IndexDocument(ctx, g.index, g.id, ownedDocument{IDs: owned}, plan.version)
+	if err != nil {
+		err = fmt.Errorf("failed to record owned index patterns, error: %s", err.Error())
+		g.log.Errorw("Failed to record owned index patterns", "error", err.Error())
+		result.AddError(err)
+	}
+
+	return result
+}
+
+// synced record and count a change that was applied.
+func (g *GitOps) synced(ctx context.Context, change Change, result *store.Result) {
+	store.Record(ctx, store.Change{Action: change.Action, Type: "index-pattern", ID: change.Desired.ID, Title: change.Desired.Title})
+	result.Add(change.Action, 1)
+	g.log.Infow("Synced index pattern", "action", change.Action, "id", change.Desired.ID, "title", change.Desired.Title, "source", change.Source)
+}
+
+// driftedAttributes return attributes of desired that differ from current, to be updated. (cleared attributes are
+// updated to empty values)
+func driftedAttributes(current, desired kibana.IndexPattern) map[string]interface{} {
+	attributes := make(map[string]interface{})
+	if current.Title != desired.Title {
+		attributes["title"] = desired.Title
+	}
+	if current.Name != desired.Name {
+		attributes["name"] = desired.Name
+	}
+	if current.TimeFieldName != desired.TimeFieldName {
+		attributes["timeFieldName"] = desired.TimeFieldName
+	}
+	if current.AllowNoIndex != desired.AllowNoIndex {
+		attributes["allowNoIndex"] = desired.AllowNoIndex
+	}
+	if !jsonEqual(current.FieldFormatMap, desired.FieldFormatMap) {
+		attributes["fieldFormatMap"] = desired.FieldFormatMap
+	}
+	if !jsonEqual(current.SourceFilters, desired.SourceFilters) {
+		attributes["sourceFilters"] = desired.SourceFilters
+	}
+	if !jsonEqual(current.RuntimeFieldMap, desired.RuntimeFieldMap) {
+		attributes["runtimeFieldMap"] = desired.RuntimeFieldMap
+	}
+	return attributes
+}
+
+// drifted check if any attribute of an index pattern differs from its definition. (JSON encoded attributes are
+// compared by value)
+func drifted(current, desired kibana.IndexPattern) bool {
+	return current.Title != desired.Title ||
+		current.Name != desired.Name ||
+		current.TimeFieldName != desired.TimeFieldName ||
+		current.AllowNoIndex != desired.AllowNoIndex ||
+		!jsonEqual(current.FieldFormatMap, desired.FieldFormatMap) ||
+		!jsonEqual(current.SourceFilters, desired.SourceFilters) ||
+		!jsonEqual(current.RuntimeFieldMap, desired.RuntimeFieldMap)
+}
+
+func jsonEqual(a, b string) bool {
+	if a == b {
+		return true
+	}
+	return reflect.DeepEqual(jsonValue(a), jsonValue(b))
+}
+
+// jsonValue decode a JSON encoded attribute, where empty values ("", {} and []) are all nil.
+func jsonValue(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(s), &value); err != nil {
+		return s
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+	}
+	return value
+}
@@ -0,0 +1,225 @@
+package gitops
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/sherifabdlnaby/rubban/config"
+	"github.com/sherifabdlnaby/rubban/log"
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+	"github.com/sherifabdlnaby/rubban/rubban/store"
+)
+
+type mockAPI struct {
+	kibana.API
+	indexPatterns map[string]kibana.IndexPattern
+	owned         *ownedDocument
+	updates       []kibana.SavedObject
+	createErr     error
+}
+
+func (m *mockAPI) IndexPatterns(ctx context.Context, filter string, fields []string) ([]kibana.IndexPattern, error) {
+	indexPatterns := make([]kibana.IndexPattern, 0)
+	for _, indexPattern := range m.indexPatterns {
+		indexPatterns = append(indexPatterns, indexPattern)
+	}
+	return indexPatterns, nil
+}
+
+func (m *mockAPI) BulkCreateIndexPattern(ctx context.Context, indexPatterns []kibana.IndexPattern) error {
+	if m.createErr != nil {
+		return m.createErr
+	}
+	for _, indexPattern := range indexPatterns {
+		m.indexPatterns[indexPattern.ID] = indexPattern
+	}
+	return nil
+}
+
+func (m *mockAPI) UpdateSavedObject(ctx context.Context, object kibana.SavedObject) error {
+	m.updates = append(m.updates, object)
+	indexPattern := m.indexPatterns[object.ID]
+	if err := json.Unmarshal(object.Attributes, &indexPattern); err != nil {
+		return err
+	}
+	m.indexPatterns[object.ID] = indexPattern
+	return nil
+}
+
+func (m *mockAPI) DeleteIndexPattern(ctx context.Context, id string) error {
+	delete(m.indexPatterns, id)
+	return nil
+}
+
+func (m *mockAPI) GetDocument(ctx context.Context, index, id string, doc interface{}) (*kibana.DocumentVersion, error) {
+	if m.owned == nil {
+		return nil, nil
+	}
+	*doc.(*ownedDocument) = *m.owned
+	return &kibana.DocumentVersion{}, nil
+}
+
+func (m *mockAPI) IndexDocument(ctx context.Context, index, id string, doc interface{}, version *kibana.DocumentVersion) error {
+	owned := doc.(ownedDocument)
+	m.owned = &owned
+	return nil
+}
+
+var files = map[string]string{
+	"team-a/logs.yml": `
+- id: logs
+  title: logs-*
+  timeFieldName: "@timestamp"
+  sourceFilters: ["secret*"]
+- id: metrics
+  title: metrics-*
+`,
+	"team-b/export.ndjson": `{"attributes":{"title":"traces-*","timeFieldName":"@timestamp"},"id":"traces","type":"index-pattern"}
+{"attributes":{"title":"Dashboard"},"id":"dashboard","type":"dashboard"}
+{"exportedCount":2,"missingRefCount":0,"missingReferences":[]}
+`,
+	"team-b/audit.json": `{"id": "audit", "title": "audit-*"}`,
+	"README.md":         `not an index pattern`,
+}
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "rubban-gitops")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	dir := writeFiles(t, files)
+	defer os.RemoveAll(dir)
+
+	definitions, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make([]string, 0)
+	for _, definition := range definitions {
+		ids = append(ids, definition.ID)
+		if definition.ID == "logs" && definition.SourceFilters != `[{"value":"secret*"}]` {
+			t.Errorf("Expected source filters to be JSON encoded, got %s", definition.SourceFilters)
+		}
+	}
+	sort.Strings(ids)
+
+	if buff, _ := json.Marshal(ids); string(buff) != `["audit","logs","metrics","traces"]` {
+		t.Errorf("Expected 4 index patterns, got %s", buff)
+	}
+
+	duplicate := writeFiles(t, map[string]string{"a.yml": "id: logs\ntitle: logs-*", "b.yml": "id: logs\ntitle: logs-*"})
+	defer os.RemoveAll(duplicate)
+	if _, err := Load(duplicate); err == nil {
+		t.Errorf("Expected duplicate index pattern IDs to fail")
+	}
+}
+
+func TestSync(t *testing.T) {
+	dir := writeFiles(t, files)
+	defer os.RemoveAll(dir)
+
+	api := &mockAPI{
+		indexPatterns: map[string]kibana.IndexPattern{
+			"logs":      {ID: "logs", Title: "logs-*", TimeFieldName: "timestamp", SourceFilters: `[{"value":"secret*"}]`},
+			"metrics":   {ID: "metrics", Title: "metrics-*", FieldFormatMap: "{}"},
+			"old":       {ID: "old", Title: "old-*"},
+			"hand-made": {ID: "hand-made", Title: "hand-made-*"},
+		},
+		owned: &ownedDocument{IDs: []string{"old", "logs", "gone"}},
+	}
+
+	g := NewGitOps(config.GitOps{Path: dir, Prune: true}, api, log.Default())
+
+	plan, err := g.Plan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actions := make(map[string]string)
+	for _, change := range plan.Changes {
+		if change.Desired != nil {
+			actions[change.Desired.ID] = change.Action
+		} else {
+			actions[change.Current.ID] = change.Action
+		}
+	}
+
+	expected := map[string]string{
+		"audit":  store.ActionCreated,
+		"traces": store.ActionCreated,
+		"logs":   store.ActionUpdated,
+		"old":    store.ActionDeleted,
+	}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Errorf("Expected changes %v, got %v", expected, actions)
+	}
+
+	result := g.Apply(context.Background(), plan)
+	if result.Failed() || result.Counts[store.ActionCreated] != 2 || result.Counts[store.ActionDeleted] != 1 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	// Only drifted attributes are updated, keeping attributes that aren't declared and references.
+	if len(api.updates) != 1 || string(api.updates[0].Attributes) != `{"timeFieldName":"@timestamp"}` || api.updates[0].References != nil {
+		t.Errorf("Expected a partial update of drifted attributes, got %+v", api.updates)
+	}
+
+	if _, ok := api.indexPatterns["hand-made"]; !ok {
+		t.Errorf("Expected index patterns not owned by rubban to be kept")
+	}
+
+	if buff, _ := json.Marshal(api.owned.IDs); string(buff) != `["audit","logs","metrics","traces"]` {
+		t.Errorf("Expected declared index patterns to be owned, got %s", buff)
+	}
+
+	// Nothing left to sync
+	plan, err = g.Plan(context.Background())
+	if err != nil || len(plan.Changes) != 0 {
+		t.Errorf("Expected no changes, got %+v, %v", plan, err)
+	}
+}
+
+func TestSyncCreateFailure(t *testing.T) {
+	dir := writeFiles(t, files)
+	defer os.RemoveAll(dir)
+
+	api := &mockAPI{
+		indexPatterns: map[string]kibana.IndexPattern{
+			"logs":    {ID: "logs", Title: "logs-*", TimeFieldName: "@timestamp", SourceFilters: `[{"value":"secret*"}]`},
+			"metrics": {ID: "metrics", Title: "metrics-*"},
+		},
+		createErr: errors.New("500 Internal Server Error"),
+	}
+
+	g := NewGitOps(config.GitOps{Path: dir, Prune: true}, api, log.Default())
+	result := g.Run(context.Background())
+	if !result.Failed() || result.Counts[store.ActionCreated] != 0 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	// Index patterns that failed to be created aren't owned, so one created by hand later is never pruned.
+	if buff, _ := json.Marshal(api.owned.IDs); string(buff) != `["logs","metrics"]` {
+		t.Errorf("Expected only existing declared index patterns to be owned, got %s", buff)
+	}
+}
@@ -0,0 +1,200 @@
+package gitops
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sherifabdlnaby/rubban/config"
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+	"github.com/sherifabdlnaby/rubban/rubban/utils"
+	"gopkg.in/yaml.v2"
+)
+
+//Definition of an index pattern declared in a file.
+type Definition struct {
+	kibana.IndexPattern
+	Source string
+}
+
+// flatDefinition is an index pattern written by hand, with attributes the same way they're configured for general patterns.
+type flatDefinition struct {
+	ID            string
+	Title         string
+	Name          string
+	TimeFieldName string
+	config.IndexPatternAttributes
+}
+
+// savedObject is an index pattern as exported by Kibana's saved objects API.
+type savedObject struct {
+	Type       string          `json:"type"`
+	ID         string          `json:"id"`
+	Attributes json.RawMessage `json:"attributes"`
+}
+
+//Load Load index pattern definitions from YAML, JSON and NDJSON (saved objects export) files under path, a file can
+//declare one index pattern or a list of them. Every index pattern must have a unique ID and a title.
+func Load(path string) ([]Definition, error) {
+	definitions := make([]Definition, 0)
+	sources := make(map[string]string)
+
+	err := filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		var indexPatterns []kibana.IndexPattern
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".yml", ".yaml", ".json":
+			indexPatterns, err = loadDocuments(file)
+		case ".ndjson":
+			indexPatterns, err = loadNDJSON(file)
+		default:
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load index patterns from [%s], error: %s", file, err.Error())
+		}
+
+		for _, indexPattern := range indexPatterns {
+			if indexPattern.ID == "" || indexPattern.Title == "" {
+				return fmt.Errorf("index pattern [%s] in [%s] must have an id and a title", indexPattern.Title, file)
+			}
+			if source, ok := sources[indexPattern.ID]; ok {
+				return fmt.Errorf("index pattern [%s] is declared in both [%s] and [%s]", indexPattern.ID, source, file)
+			}
+			sources[indexPattern.ID] = file
+			definitions = append(definitions, Definition{IndexPattern: indexPattern, Source: file})
+		}
+
+		return nil
+	})
+
+	return definitions, err
+}
+
+// loadDocuments load index patterns from a YAML (or JSON) file, that may contain multiple YAML documents.
+func loadDocuments(file string) ([]kibana.IndexPattern, error) {
+	buff, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	indexPatterns := make([]kibana.IndexPattern, 0)
+	decoder := yaml.NewDecoder(bytes.NewReader(buff))
+	for {
+		var document interface{}
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		objects, ok := document.([]interface{})
+		if !ok {
+			objects = []interface{}{document}
+		}
+
+		for _, object := range objects {
+			if object == nil {
+				continue
+			}
+
+			// Re-encode as JSON, so both hand written and exported index patterns are decoded the same way.
+			raw, err := json.Marshal(utils.StringifyMapKeys(object))
+			if err != nil {
+				return nil, err
+			}
+
+			indexPattern, ok, err := decodeObject(raw, false)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				indexPatterns = append(indexPatterns, indexPattern)
+			}
+		}
+	}
+
+	return indexPatterns, nil
+}
+
+// loadNDJSON load index patterns from a saved objects export, other saved objects types are ignored.
+func loadNDJSON(file string) ([]kibana.IndexPattern, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	indexPatterns := make([]kibana.IndexPattern, 0)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		indexPattern, ok, err := decodeObject(line, true)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			indexPatterns = append(indexPatterns, indexPattern)
+		}
+	}
+
+	return indexPatterns, scanner.Err()
+}
+
+// decodeObject decode an index pattern either as a saved object or a hand written definition (unless savedObjectsOnly),
+// returns false if it's a saved object of another type.
+func decodeObject(raw []byte, savedObjectsOnly bool) (kibana.IndexPattern, bool, error) {
+	object := savedObject{}
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return kibana.IndexPattern{}, false, err
+	}
+
+	if object.Attributes != nil {
+		if object.Type != "" && object.Type != "index-pattern" {
+			return kibana.IndexPattern{}, false, nil
+		}
+
+		indexPattern := kibana.IndexPattern{}
+		if err := json.Unmarshal(object.Attributes, &indexPattern); err != nil {
+			return kibana.IndexPattern{}, false, err
+		}
+		indexPattern.ID = object.ID
+		return indexPattern, true, nil
+	}
+
+	// Export summary and other lines that aren't saved objects.
+	if savedObjectsOnly {
+		return kibana.IndexPattern{}, false, nil
+	}
+
+	definition := flatDefinition{}
+	if err := json.Unmarshal(raw, &definition); err != nil {
+		return kibana.IndexPattern{}, false, err
+	}
+
+	indexPattern := kibana.NewIndexPatternAttributes(definition.IndexPatternAttributes)
+	indexPattern.ID = definition.ID
+	indexPattern.Title = definition.Title
+	indexPattern.Name = definition.Name
+	indexPattern.TimeFieldName = definition.TimeFieldName
+	return indexPattern, true, nil
+}
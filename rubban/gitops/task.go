@@ -0,0 +1,32 @@
+package gitops
+
+import (
+	"context"
+
+	"github.com/sherifabdlnaby/rubban/rubban/store"
+)
+
+//Run Run GitOps sync task
+func (g *GitOps) Run(ctx context.Context) store.Result {
+
+	// 1- Compare Declared Index Patterns with Kibana's
+	plan, err := g.Plan(ctx)
+	if err != nil {
+		g.log.Errorw("Failed to plan index patterns sync", "path", g.Path, "error", err.Error())
+		result := store.NewResult()
+		result.AddError(err)
+		return result
+	}
+
+	if len(plan.Changes) == 0 {
+		g.log.Debugw("Index patterns are in sync", "path", g.Path)
+	}
+
+	// 2- Apply Changes
+	return g.Apply(ctx, plan)
+}
+
+//Name Return Task Name
+func (g *GitOps) Name() string {
+	return g.name
+}
@@ -5,6 +5,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
 	"regexp"
 	"strings"
 
@@ -51,109 +55,158 @@ func (a *APIVer7) Info(ctx context.Context) (Info, error) {
 	return info, err
 }
 
-//Indices Get Indices match supported filter (support wildcards)
+//ClusterStateVersion Get Elasticsearch's Cluster State Version, the version changes on every cluster state change
+//(e.g index creation or deletion), so it's a cheap way to know if there might be new indices.
+func (a *APIVer7) ClusterStateVersion(ctx context.Context) (int64, error) {
+	response := struct {
+		Version int64 `json:"version"`
+	}{}
+
+	resp, err := a.client.Post(ctx, "/api/console/proxy?path=_cluster/state/version&method=GET", nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("failed to get cluster state version, error: %s", resp.Status)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return 0, err
+	}
+
+	return response.Version, nil
+}
+
+//Indices Get Indices match supported filter (support wildcards), indices of remote clusters (cross cluster search) are
+//matched by a cluster prefix (cluster:logs-*) and returned with it.
 func (a *APIVer7) Indices(ctx context.Context, filter string) ([]Index, error) {
+	if strings.Contains(filter, ":") {
+		return a.resolveIndices(ctx, filter)
+	}
+
 	indices := make([]Index, 0)
 	resp, err := a.client.Post(ctx, fmt.Sprintf("/api/console/proxy?path=_cat/indices/%s?format=json&h=index&method=GET", filter), nil)
 	if err != nil {
-		return indices, err
+		return nil, err
 	}
 	defer resp.Body.Close()
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		err := json.NewDecoder(resp.Body).Decode(&indices)
-		if err != nil {
-			return nil, err
-		}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to get indices [%s], error: %s", filter, resp.Status)
 	}
-	return indices, err
-}
 
-//FindIndexPatternResponse Used to Decode JSON Response for Querying Index Patterns
-type FindIndexPatternResponse struct {
-	Hits struct {
-		Hits []struct {
-			ID     string `json:"_id"`
-			Source struct {
-				IndexPattern struct {
-					Title         string `json:"title"`
-					TimeFieldName string `json:"timeFieldName"`
-				} `json:"index-pattern"`
-			} `json:"_source"`
-		} `json:"hits"`
-	} `json:"hits"`
+	err = json.NewDecoder(resp.Body).Decode(&indices)
+	if err != nil {
+		return nil, err
+	}
+	return indices, nil
 }
 
-var idxPatternID = regexp.MustCompile(`(index-pattern:)(.*)`)
+// resolveIndices Get local and remote indices matching filter using the resolve index API (Elasticsearch 7.9+), as
+// _cat/indices only sees local indices.
+func (a *APIVer7) resolveIndices(ctx context.Context, filter string) ([]Index, error) {
+	response := struct {
+		Indices []struct {
+			Name string `json:"name"`
+		} `json:"indices"`
+	}{}
 
-//IndexPatterns Get IndexPatterns from kibana matching the supplied filter (support wildcards)
-func (a *APIVer7) IndexPatterns(ctx context.Context, filter string, fields []string) ([]IndexPattern, error) {
+	resp, err := a.client.Post(ctx, "/api/console/proxy?path="+url.QueryEscape("_resolve/index/"+filter)+"&method=GET", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
 
-	// As Index Pattern Names in Kibana Index is of type text. It CANNOT be queried with wildcards (ex logs-*-xyz-*),
-	// because It's analyzed and tokenized, so it can be looked up using exact phrase (that remove punc like * - . etc)
-	// which is not ideal, here we do a query that will get all results + some false positives, then w reiterate to
-	// eliminate these false positives. It's okay to do that since number of Index patters can rarely be 1000+ per pattern.
-	// so it's okay to do these extra steps and won't add much overhead.
-
-	var IndexPatterns = make([]IndexPattern, 0)
-
-	requestBody := fmt.Sprintf(`{
-	  "_source": ["index-pattern.title","index-pattern.timeFieldName"],
-      "size": 10000,
-	  "query": {
-			"bool": {
-		  "must": [
-			{
-			  "query_string" : {
-				"query" : "%s",
-				"auto_generate_synonyms_phrase_query": true,
-				"analyze_wildcard": true,
-				"default_operator": "AND"
-				, "fields": ["index-pattern.title"]
-				, "fuzziness": 0.0
-				, "phrase_slop": 0
-			}
-			},
-			{
-			  "match_phrase": {
-				"type": {
-				  "query": "index-pattern"
-				}
-			  }
-			}
-		  ],
-		  "filter": [],
-		  "should": [],
-		  "must_not": []
-		}
-	  }
-	}`, filter)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to resolve indices [%s], error: %s", filter, resp.Status)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return nil, err
+	}
+
+	indices := make([]Index, 0, len(response.Indices))
+	for _, index := range response.Indices {
+		indices = append(indices, Index{Name: index.Name})
+	}
+	return indices, nil
+}
+
+//Fields Get Fields of indices matching the supplied pattern (support wildcards)
+func (a *APIVer7) Fields(ctx context.Context, pattern string) ([]Field, error) {
+	response := struct {
+		Fields []Field `json:"fields"`
+	}{}
 
-	resp, err := a.client.Post(ctx, "/api/console/proxy?path=.kibana/_search&method=POST", strings.NewReader(requestBody))
+	resp, err := a.client.Get(ctx, fmt.Sprintf("/api/index_patterns/_fields_for_wildcard?pattern=%s", url.QueryEscape(pattern)), nil)
 	if err != nil {
-		return IndexPatterns, err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	response := FindIndexPatternResponse{}
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		err := json.NewDecoder(resp.Body).Decode(&response)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to get fields for pattern [%s], error: %s", pattern, resp.Status)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Fields, nil
+}
+
+// indexPatternFields are attributes of index patterns fetched when listing them.
+var indexPatternFields = []string{"title", "name", "timeFieldName", "fieldFormatMap", "sourceFilters", "runtimeFieldMap", "allowNoIndex"}
+
+//IndexPatterns Get IndexPatterns from kibana matching the supplied filter (support wildcards). All index patterns are
+//paged through, and an error is returned if any page fails, so callers never act on a partial list.
+func (a *APIVer7) IndexPatterns(ctx context.Context, filter string, fields []string) ([]IndexPattern, error) {
+
+	// As Index Pattern titles in Kibana Index are of type text, they CANNOT be queried with wildcards (ex logs-*-xyz-*),
+	// so all index patterns are fetched and matched against filter here.
+	params := url.Values{}
+	params.Set("type", "index-pattern")
+	for _, field := range indexPatternFields {
+		params.Add("fields", field)
+	}
+	params.Set("per_page", "1000")
+
+	regex := regexp.MustCompile("^" + utils.PatternToRegex(filter) + "$")
+
+	indexPatterns := make([]IndexPattern, 0)
+	for page, total := 1, 0; ; page++ {
+		params.Set("page", fmt.Sprint(page))
+
+		response, err := a.findSavedObjects(ctx, params)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("failed to get index patterns, error: %s", err.Error())
 		}
-	}
 
-	regex := regexp.MustCompile(utils.PatternToRegex(filter))
+		for _, savedObject := range response.SavedObjects {
+			indexPattern := IndexPattern{}
+			if err := json.Unmarshal(savedObject.Attributes, &indexPattern); err != nil {
+				return nil, fmt.Errorf("failed to decode index pattern [%s], error: %s", savedObject.ID, err.Error())
+			}
+			indexPattern.ID = savedObject.ID
 
-	for _, hit := range response.Hits.Hits {
-		if regex.MatchString(hit.Source.IndexPattern.Title) {
-			IndexPatterns = append(IndexPatterns, IndexPattern{
-				ID:            idxPatternID.ReplaceAllString(hit.ID, "$2"),
-				Title:         hit.Source.IndexPattern.Title,
-				TimeFieldName: hit.Source.IndexPattern.TimeFieldName,
-			})
+			if regex.MatchString(indexPattern.Title) {
+				indexPatterns = append(indexPatterns, indexPattern)
+			}
+		}
+
+		total += len(response.SavedObjects)
+		if len(response.SavedObjects) == 0 || total >= response.Total {
+			if total < response.Total {
+				return nil, fmt.Errorf("failed to get index patterns, got %d of %d", total, response.Total)
+			}
+			return indexPatterns, nil
 		}
 	}
-	return IndexPatterns, err
 }
 
 //BulkCreateIndexPattern Add Index Patterns to Kibana
@@ -165,13 +218,12 @@ func (a *APIVer7) BulkCreateIndexPattern(ctx context.Context, indexPattern []Ind
 	// Prepare Requests
 	bulkRequest := make([]BulkIndexPattern, 0)
 	for _, pattern := range indexPattern {
+		attributes := pattern
+		attributes.ID = ""
 		bulkRequest = append(bulkRequest, BulkIndexPattern{
-			Type: "index-pattern",
-			ID:   pattern.ID,
-			Attributes: IndexPattern{
-				Title:         pattern.Title,
-				TimeFieldName: pattern.TimeFieldName,
-			},
+			Type:       "index-pattern",
+			ID:         pattern.ID,
+			Attributes: attributes,
 		})
 	}
 
@@ -194,3 +246,410 @@ func (a *APIVer7) BulkCreateIndexPattern(ctx context.Context, indexPattern []Ind
 
 	return nil
 }
+
+//GetIndexPattern Get IndexPattern by ID
+func (a *APIVer7) GetIndexPattern(ctx context.Context, id string) (IndexPattern, bool, error) {
+	resp, err := a.client.Get(ctx, "/api/saved_objects/index-pattern/"+url.PathEscape(id), nil)
+	if err != nil {
+		return IndexPattern{}, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return IndexPattern{}, false, nil
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return IndexPattern{}, false, fmt.Errorf("failed to get index pattern [%s], error: %s", id, resp.Status)
+	}
+
+	savedObject := SavedIndexPattern{}
+	err = json.NewDecoder(resp.Body).Decode(&savedObject)
+	if err != nil {
+		return IndexPattern{}, false, err
+	}
+
+	indexPattern := savedObject.Attributes
+	indexPattern.ID = savedObject.ID
+	return indexPattern, true, nil
+}
+
+//DeleteIndexPattern Delete IndexPattern by ID (deleting an index pattern that doesn't exist is a no-op)
+func (a *APIVer7) DeleteIndexPattern(ctx context.Context, id string) error {
+	resp, err := a.client.Delete(ctx, "/api/saved_objects/index-pattern/"+url.PathEscape(id), nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete index pattern [%s], error: %s", id, err.Error())
+	}
+
+	_ = resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to delete index pattern [%s], error: %s", id, resp.Status)
+	}
+
+	return nil
+}
+
+//CreateRule Create an alerting Rule with ID (Kibana 7.13+), returns false if a rule with ID already exists.
+func (a *APIVer7) CreateRule(ctx context.Context, id string, rule json.RawMessage) (bool, error) {
+	resp, err := a.client.Post(ctx, "/api/alerting/rule/"+url.PathEscape(id), bytes.NewReader(rule))
+	if err != nil {
+		return false, fmt.Errorf("failed to create rule [%s], error: %s", id, err.Error())
+	}
+
+	_ = resp.Body.Close()
+	if resp.StatusCode == http.StatusConflict {
+		return false, nil
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return false, fmt.Errorf("failed to create rule [%s], error: %s", id, resp.Status)
+	}
+
+	return true, nil
+}
+
+//DeleteRule Delete an alerting Rule by ID (Kibana 7.13+)
+func (a *APIVer7) DeleteRule(ctx context.Context, id string) error {
+	resp, err := a.client.Delete(ctx, "/api/alerting/rule/"+url.PathEscape(id), nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete rule [%s], error: %s", id, err.Error())
+	}
+
+	_ = resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to delete rule [%s], error: %s", id, resp.Status)
+	}
+
+	return nil
+}
+
+//FindSavedObjects Get all SavedObjects matching query, fails if the listing is incomplete (saved objects changed while
+//paging through them)
+func (a *APIVer7) FindSavedObjects(ctx context.Context, query SavedObjectsQuery) ([]SavedObject, error) {
+	params := url.Values{}
+	for _, objectType := range query.Types {
+		params.Add("type", objectType)
+	}
+	if query.HasReference != nil {
+		hasReference, err := json.Marshal(map[string]string{"type": query.HasReference.Type, "id": query.HasReference.ID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to JSON marshaling saved objects query")
+		}
+		params.Set("has_reference", string(hasReference))
+	}
+	params.Set("per_page", "1000")
+
+	savedObjects := make([]SavedObject, 0)
+	for page, total := 1, -1; ; page++ {
+		params.Set("page", fmt.Sprint(page))
+
+		response, err := a.findSavedObjects(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+
+		// Saved objects added or deleted while paging shift pages, a listing whose total changed or that doesn't add
+		// up to total is incomplete.
+		if total >= 0 && response.Total != total {
+			return nil, fmt.Errorf("failed to find saved objects, they changed while listing them (%d then %d)", total, response.Total)
+		}
+		total = response.Total
+
+		savedObjects = append(savedObjects, response.SavedObjects...)
+		if len(response.SavedObjects) == 0 || len(savedObjects) >= total {
+			if len(savedObjects) != total {
+				return nil, fmt.Errorf("failed to find saved objects, got %d of %d", len(savedObjects), total)
+			}
+			return savedObjects, nil
+		}
+	}
+}
+
+func (a *APIVer7) findSavedObjects(ctx context.Context, params url.Values) (SavedObjectsPage, error) {
+	response := SavedObjectsPage{}
+
+	resp, err := a.client.Get(ctx, "/api/saved_objects/_find?"+params.Encode(), nil)
+	if err != nil {
+		return response, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return response, fmt.Errorf("failed to find saved objects, error: %s", resp.Status)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	return response, err
+}
+
+//GetSavedObject Get SavedObject by type and ID
+func (a *APIVer7) GetSavedObject(ctx context.Context, objectType, id string) (SavedObject, bool, error) {
+	resp, err := a.client.Get(ctx, fmt.Sprintf("/api/saved_objects/%s/%s", url.PathEscape(objectType), url.PathEscape(id)), nil)
+	if err != nil {
+		return SavedObject{}, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return SavedObject{}, false, nil
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return SavedObject{}, false, fmt.Errorf("failed to get saved object [%s/%s], error: %s", objectType, id, resp.Status)
+	}
+
+	savedObject := SavedObject{}
+	err = json.NewDecoder(resp.Body).Decode(&savedObject)
+	if err != nil {
+		return SavedObject{}, false, err
+	}
+
+	return savedObject, true, nil
+}
+
+//UpdateSavedObject Update attributes (merged with current ones) and references (replacing current ones, unless nil) of
+//a SavedObject
+func (a *APIVer7) UpdateSavedObject(ctx context.Context, object SavedObject) error {
+	attributes := object.Attributes
+	if attributes == nil {
+		attributes = json.RawMessage("{}")
+	}
+
+	body := map[string]interface{}{"attributes": attributes}
+	if object.References != nil {
+		body["references"] = object.References
+	}
+
+	buff, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to JSON marshaling saved object")
+	}
+
+	resp, err := a.client.Put(ctx, fmt.Sprintf("/api/saved_objects/%s/%s", url.PathEscape(object.Type), url.PathEscape(object.ID)), bytes.NewReader(buff))
+	if err != nil {
+		return fmt.Errorf("failed to update saved object [%s/%s], error: %s", object.Type, object.ID, err.Error())
+	}
+
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to update saved object [%s/%s], error: %s", object.Type, object.ID, resp.Status)
+	}
+
+	return nil
+}
+
+//ExportSavedObjects Export SavedObjects of types (with their export details as the last line) as NDJSON
+func (a *APIVer7) ExportSavedObjects(ctx context.Context, types []string) ([]byte, error) {
+	buff, err := json.Marshal(map[string]interface{}{"type": types})
+	if err != nil {
+		return nil, fmt.Errorf("failed to JSON marshaling export request")
+	}
+
+	resp, err := a.client.Post(ctx, "/api/saved_objects/_export", bytes.NewReader(buff))
+	if err != nil {
+		return nil, fmt.Errorf("failed to export saved objects, error: %s", err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to export saved objects, error: %s", resp.Status)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+//ImportSavedObjects Import SavedObjects from NDJSON, overwriting existing ones if overwrite is true
+func (a *APIVer7) ImportSavedObjects(ctx context.Context, ndjson []byte, overwrite bool) (ImportResult, error) {
+	result := ImportResult{}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "export.ndjson")
+	if err != nil {
+		return result, err
+	}
+	if _, err = part.Write(ndjson); err != nil {
+		return result, err
+	}
+	if err = writer.Close(); err != nil {
+		return result, err
+	}
+
+	resp, err := a.client.PostMultipart(ctx, fmt.Sprintf("/api/saved_objects/_import?overwrite=%t", overwrite), writer.FormDataContentType(), body)
+	if err != nil {
+		return result, fmt.Errorf("failed to import saved objects, error: %s", err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return result, fmt.Errorf("failed to import saved objects, error: %s", resp.Status)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	return result, err
+}
+
+//DefaultIndexPattern Get ID of the Default IndexPattern (Empty if not set)
+func (a *APIVer7) DefaultIndexPattern(ctx context.Context) (string, error) {
+	resp, err := a.client.Get(ctx, "/api/kibana/settings", nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("failed to get advanced settings, error: %s", resp.Status)
+	}
+
+	settings := Settings{}
+	err = json.NewDecoder(resp.Body).Decode(&settings)
+	if err != nil {
+		return "", err
+	}
+
+	defaultIndex, _ := settings.Settings["defaultIndex"].UserValue.(string)
+	return defaultIndex, nil
+}
+
+//SetDefaultIndexPattern Set Default IndexPattern by ID
+func (a *APIVer7) SetDefaultIndexPattern(ctx context.Context, id string) error {
+	buff, err := json.Marshal(map[string]map[string]string{"changes": {"defaultIndex": id}})
+	if err != nil {
+		return fmt.Errorf("failed to JSON marshaling default index pattern")
+	}
+
+	resp, err := a.client.Post(ctx, "/api/kibana/settings", bytes.NewReader(buff))
+	if err != nil {
+		return fmt.Errorf("failed to set default index pattern, error: %s", err.Error())
+	}
+
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to set default index pattern, error: %s", resp.Status)
+	}
+
+	return nil
+}
+
+//consoleProxyPath Return path of Kibana's Console Proxy API for an Elasticsearch request
+func consoleProxyPath(method, path string) string {
+	return fmt.Sprintf("/api/console/proxy?path=%s&method=%s", url.QueryEscape(path), method)
+}
+
+//GetDocument Get an Elasticsearch Document and decode its source into doc, returns a nil version if it doesn't exist.
+func (a *APIVer7) GetDocument(ctx context.Context, index, id string, doc interface{}) (*DocumentVersion, error) {
+	resp, err := a.client.Post(ctx, consoleProxyPath("GET", fmt.Sprintf("%s/_doc/%s", index, url.PathEscape(id))), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to get document [%s/%s], error: %s", index, id, resp.Status)
+	}
+
+	response := struct {
+		DocumentVersion
+		Found  bool            `json:"found"`
+		Source json.RawMessage `json:"_source"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Found {
+		return nil, nil
+	}
+
+	err = json.Unmarshal(response.Source, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.DocumentVersion, nil
+}
+
+//IndexDocument Create or Update an Elasticsearch Document. If version is nil the document is only created if it doesn't
+//exist, otherwise it's only updated if it wasn't changed since version, returns ErrConflict if either is not the case.
+func (a *APIVer7) IndexDocument(ctx context.Context, index, id string, doc interface{}, version *DocumentVersion) error {
+	buff, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to JSON marshaling document")
+	}
+
+	path := fmt.Sprintf("%s/_create/%s?refresh=true", index, url.PathEscape(id))
+	if version != nil {
+		path = fmt.Sprintf("%s/_doc/%s?refresh=true&if_seq_no=%d&if_primary_term=%d", index, url.PathEscape(id), version.SeqNo, version.PrimaryTerm)
+	}
+
+	resp, err := a.client.Post(ctx, consoleProxyPath("PUT", path), bytes.NewReader(buff))
+	if err != nil {
+		return fmt.Errorf("failed to index document, error: %s", err.Error())
+	}
+
+	_ = resp.Body.Close()
+	if resp.StatusCode == http.StatusConflict {
+		return ErrConflict
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to index document [%s/%s], error: %s", index, id, resp.Status)
+	}
+
+	return nil
+}
+
+//SearchDocuments Search Elasticsearch Documents using a search request body, returns source of matching documents
+//(empty if index doesn't exist).
+func (a *APIVer7) SearchDocuments(ctx context.Context, index string, query interface{}) ([]json.RawMessage, error) {
+	buff, err := json.Marshal(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to JSON marshaling search query")
+	}
+
+	resp, err := a.client.Post(ctx, consoleProxyPath("POST", index+"/_search"), bytes.NewReader(buff))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return []json.RawMessage{}, nil
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to search documents in [%s], error: %s", index, resp.Status)
+	}
+
+	response := struct {
+		Hits struct {
+			Hits []struct {
+				Source json.RawMessage `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return nil, err
+	}
+
+	documents := make([]json.RawMessage, 0, len(response.Hits.Hits))
+	for _, hit := range response.Hits.Hits {
+		documents = append(documents, hit.Source)
+	}
+
+	return documents, nil
+}
@@ -0,0 +1,167 @@
+package kibana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sherifabdlnaby/rubban/config"
+	"github.com/sherifabdlnaby/rubban/log"
+)
+
+func newTestAPI(t *testing.T, handler http.HandlerFunc) (*APIVer7, *httptest.Server) {
+	server := httptest.NewServer(handler)
+
+	api, err := NewAPIVer7(config.Kibana{Host: server.URL}, log.Default())
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	return api, server
+}
+
+// findHandler serve total index patterns titled logs-<i>-* through _find, failing page failPage (if any).
+func findHandler(total, failPage int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		if r.URL.Path != "/api/saved_objects/_find" || r.URL.Query().Get("type") != "index-pattern" || page == failPage {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		response := SavedObjectsPage{Page: page, PerPage: perPage, Total: total, SavedObjects: []SavedObject{}}
+		for i := (page - 1) * perPage; i < page*perPage && i < total; i++ {
+			response.SavedObjects = append(response.SavedObjects, SavedObject{
+				Type:       "index-pattern",
+				ID:         fmt.Sprint(i),
+				Attributes: json.RawMessage(fmt.Sprintf(`{"title":"logs-%d-*","timeFieldName":"@timestamp"}`, i)),
+			})
+		}
+		_ = json.NewEncoder(w).Encode(response)
+	}
+}
+
+// TestIndexPatterns tests paging through all index patterns and matching them against the filter.
+func TestIndexPatterns(t *testing.T) {
+	api, server := newTestAPI(t, findHandler(12345, 0))
+	defer server.Close()
+
+	indexPatterns, err := api.IndexPatterns(context.Background(), "*", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(indexPatterns) != 12345 {
+		t.Fatalf("expected all 12345 index patterns, got %d", len(indexPatterns))
+	}
+	if indexPatterns[12344].ID != "12344" || indexPatterns[12344].Title != "logs-12344-*" {
+		t.Errorf("unexpected index pattern %v", indexPatterns[12344])
+	}
+
+	indexPatterns, err = api.IndexPatterns(context.Background(), "logs-1-*", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(indexPatterns) != 1 || indexPatterns[0].ID != "1" {
+		t.Errorf("expected only logs-1-* to match, got %v", indexPatterns)
+	}
+}
+
+// TestIndexPatternsFailure tests that a failing page fails the whole listing instead of returning a partial one.
+func TestIndexPatternsFailure(t *testing.T) {
+	api, server := newTestAPI(t, findHandler(2500, 2))
+	defer server.Close()
+
+	indexPatterns, err := api.IndexPatterns(context.Background(), "*", nil)
+	if err == nil {
+		t.Fatalf("expected an error, got %d index patterns", len(indexPatterns))
+	}
+}
+
+// TestIndicesFailure tests that a non-2xx response is an error rather than no indices.
+func TestIndicesFailure(t *testing.T) {
+	api, server := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	defer server.Close()
+
+	for _, filter := range []string{"logs-*", "cluster_a:logs-*"} {
+		indices, err := api.Indices(context.Background(), filter)
+		if err == nil {
+			t.Errorf("expected an error for [%s], got %v", filter, indices)
+		}
+	}
+}
+
+// TestFindSavedObjectsIncomplete tests that listings of saved objects changing while paging through them fail.
+func TestFindSavedObjectsIncomplete(t *testing.T) {
+	for name, totals := range map[string][]int{
+		"deleted while paging":    {2500, 2400, 2400},
+		"added while paging":      {2500, 2600, 2600, 2600},
+		"pages shorter than said": {2500, 2500, 2500},
+	} {
+		t.Run(name, func(t *testing.T) {
+			api, server := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+				page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+				// The last page is missing objects, and pages after it are empty.
+				count := 1000
+				if page == len(totals) {
+					count = 100
+				} else if page > len(totals) {
+					page, count = len(totals), 0
+				}
+				response := SavedObjectsPage{Page: page, Total: totals[page-1], SavedObjects: []SavedObject{}}
+				for i := 0; i < count; i++ {
+					response.SavedObjects = append(response.SavedObjects, SavedObject{Type: "visualization", ID: fmt.Sprintf("%d-%d", page, i)})
+				}
+				_ = json.NewEncoder(w).Encode(response)
+			})
+			defer server.Close()
+
+			if _, err := api.FindSavedObjects(context.Background(), SavedObjectsQuery{Types: []string{"visualization"}}); err == nil {
+				t.Errorf("expected an incomplete listing to fail")
+			}
+		})
+	}
+}
+
+// TestSpace tests that reads and writes of saved objects and settings all go to the configured space.
+func TestSpace(t *testing.T) {
+	for space, prefix := range map[string]string{"": "/api/", "default": "/api/", "team-a": "/s/team-a/api/"} {
+		paths := make([]string, 0)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			paths = append(paths, r.URL.Path)
+			_, _ = w.Write([]byte(`{"saved_objects":[],"total":0,"settings":{}}`))
+		}))
+
+		api, err := NewAPIVer7(config.Kibana{Host: server.URL, Space: space}, log.Default())
+		if err != nil {
+			server.Close()
+			t.Fatal(err)
+		}
+
+		ctx := context.Background()
+		_, _ = api.IndexPatterns(ctx, "*", nil)
+		_, _, _ = api.GetIndexPattern(ctx, "logs")
+		_, _ = api.FindSavedObjects(ctx, SavedObjectsQuery{Types: []string{"index-pattern"}})
+		_, _ = api.DefaultIndexPattern(ctx)
+		_ = api.BulkCreateIndexPattern(ctx, []IndexPattern{{ID: "logs", Title: "logs-*"}})
+		_ = api.DeleteIndexPattern(ctx, "logs")
+		_ = api.SetDefaultIndexPattern(ctx, "logs")
+		server.Close()
+
+		if len(paths) != 7 {
+			t.Errorf("expected 7 requests for space [%s], got %v", space, paths)
+		}
+		for _, path := range paths {
+			if !strings.HasPrefix(path, prefix) {
+				t.Errorf("expected request of space [%s] to %s, got %s", space, prefix, path)
+			}
+		}
+	}
+}
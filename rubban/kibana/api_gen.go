@@ -2,6 +2,7 @@ package kibana
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
 	"github.com/Masterminds/semver/v3"
@@ -43,17 +44,97 @@ func (a *APIGen) Info(ctx context.Context) (Info, error) {
 	panic("Should Not Be Called from Gen Pattern.")
 }
 
+//ClusterStateVersion Get Elasticsearch's Cluster State Version
+func (a *APIGen) ClusterStateVersion(ctx context.Context) (int64, error) {
+	panic("Should Not Be Called from Gen Pattern.")
+}
+
 //Indices Get Indices match supported filter (support wildcards)
 func (a *APIGen) Indices(ctx context.Context, filter string) ([]Index, error) {
 	panic("Should Not Be Called from Gen Pattern.")
 }
 
+//Fields Get Fields of indices matching the supplied pattern (support wildcards)
+func (a *APIGen) Fields(ctx context.Context, pattern string) ([]Field, error) {
+	panic("Should Not Be Called from Gen Pattern.")
+}
+
 //IndexPatterns Get IndexPatterns from kibana matching the supplied filter (support wildcards)
 func (a *APIGen) IndexPatterns(ctx context.Context, filter string, fields []string) ([]IndexPattern, error) {
 	panic("Should Not Be Called from Gen Pattern.")
 }
 
+//GetIndexPattern Get IndexPattern by ID
+func (a *APIGen) GetIndexPattern(ctx context.Context, id string) (IndexPattern, bool, error) {
+	panic("Should Not Be Called from Gen Pattern.")
+}
+
+//DeleteIndexPattern Delete IndexPattern by ID
+func (a *APIGen) DeleteIndexPattern(ctx context.Context, id string) error {
+	panic("Should Not Be Called from Gen Pattern.")
+}
+
+//FindSavedObjects Get all SavedObjects matching query
+func (a *APIGen) FindSavedObjects(ctx context.Context, query SavedObjectsQuery) ([]SavedObject, error) {
+	panic("Should Not Be Called from Gen Pattern.")
+}
+
+//GetSavedObject Get SavedObject by type and ID
+func (a *APIGen) GetSavedObject(ctx context.Context, objectType, id string) (SavedObject, bool, error) {
+	panic("Should Not Be Called from Gen Pattern.")
+}
+
+//UpdateSavedObject Update a SavedObject
+func (a *APIGen) UpdateSavedObject(ctx context.Context, object SavedObject) error {
+	panic("Should Not Be Called from Gen Pattern.")
+}
+
+//DefaultIndexPattern Get ID of the Default IndexPattern
+func (a *APIGen) DefaultIndexPattern(ctx context.Context) (string, error) {
+	panic("Should Not Be Called from Gen Pattern.")
+}
+
+//SetDefaultIndexPattern Set Default IndexPattern by ID
+func (a *APIGen) SetDefaultIndexPattern(ctx context.Context, id string) error {
+	panic("Should Not Be Called from Gen Pattern.")
+}
+
+//GetDocument Get an Elasticsearch Document
+func (a *APIGen) GetDocument(ctx context.Context, index, id string, doc interface{}) (*DocumentVersion, error) {
+	panic("Should Not Be Called from Gen Pattern.")
+}
+
+//IndexDocument Create or Update an Elasticsearch Document
+func (a *APIGen) IndexDocument(ctx context.Context, index, id string, doc interface{}, version *DocumentVersion) error {
+	panic("Should Not Be Called from Gen Pattern.")
+}
+
+//SearchDocuments Search Elasticsearch Documents
+func (a *APIGen) SearchDocuments(ctx context.Context, index string, query interface{}) ([]json.RawMessage, error) {
+	panic("Should Not Be Called from Gen Pattern.")
+}
+
 //BulkCreateIndexPattern Add Index Patterns to Kibana
 func (a *APIGen) BulkCreateIndexPattern(ctx context.Context, indexPatterns []IndexPattern) error {
 	panic("Should Not Be Called from Gen Pattern.")
 }
+
+//ExportSavedObjects Export SavedObjects of types as NDJSON
+func (a *APIGen) ExportSavedObjects(ctx context.Context, types []string) ([]byte, error) {
+	panic("Should Not Be Called from Gen Pattern.")
+}
+
+//ImportSavedObjects Import SavedObjects from NDJSON
+func (a *APIGen) ImportSavedObjects(ctx context.Context, ndjson []byte, overwrite bool) (ImportResult, error) {
+	panic("Should Not Be Called from Gen Pattern.")
+}
+
+//CreateRule Create an alerting Rule with ID
+func (a *APIGen) CreateRule(ctx context.Context, id string, rule json.RawMessage) (bool, error) {
+	panic("Should Not Be Called from Gen Pattern.")
+}
+
+//DeleteRule Delete an alerting Rule by ID
+func (a *APIGen) DeleteRule(ctx context.Context, id string) error {
+	panic("Should Not Be Called from Gen Pattern.")
+}
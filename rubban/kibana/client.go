@@ -22,6 +22,7 @@ type Client struct {
 	baseURL  *url.URL
 	username string
 	password string
+	space    string
 	http     *http.Client
 	logger   log.Logger
 }
@@ -44,6 +45,7 @@ func NewKibanaClient(config config.Kibana, logger log.Logger) (*Client, error) {
 		baseURL:  baseURL,
 		username: config.User,
 		password: config.Password,
+		space:    config.Space,
 		http: &http.Client{
 			/* #nosec */
 			Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
@@ -54,6 +56,10 @@ func NewKibanaClient(config config.Kibana, logger log.Logger) (*Client, error) {
 }
 
 func (c *Client) getURLFromPath(path string) string {
+	// Requests to a non default space are prefixed with the space's path.
+	if c.space != "" && c.space != "default" {
+		return c.baseURL.String() + "/s/" + url.PathEscape(c.space) + path
+	}
 	return c.baseURL.String() + path
 }
 
@@ -93,6 +99,16 @@ func (c *Client) Post(ctx context.Context, path string, body io.Reader) (*http.R
 	return c.http.Do(req)
 }
 
+//PostMultipart Perform a POST Request with a multipart/form-data body to Kibana
+func (c *Client) PostMultipart(ctx context.Context, path string, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := c.newRequest(ctx, "POST", path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return c.http.Do(req)
+}
+
 //Put Perform a PUT Request to Kibana
 func (c *Client) Put(ctx context.Context, path string, body io.Reader) (*http.Response, error) {
 	req, err := c.newRequest(ctx, "PUT", path, body)
@@ -102,6 +118,15 @@ func (c *Client) Put(ctx context.Context, path string, body io.Reader) (*http.Re
 	return c.http.Do(req)
 }
 
+//Delete Perform a DELETE Request to Kibana
+func (c *Client) Delete(ctx context.Context, path string, body io.Reader) (*http.Response, error) {
+	req, err := c.newRequest(ctx, "DELETE", path, body)
+	if err != nil {
+		return nil, err
+	}
+	return c.http.Do(req)
+}
+
 //Validate Validate connection to Kibana by pinging /status api.
 func (c *Client) Validate(ctx context.Context, retry int, waitTime time.Duration) error {
 	var err error
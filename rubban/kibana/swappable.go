@@ -0,0 +1,132 @@
+package kibana
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+//SwappableAPI forwards every call to an API that can be swapped while in use, it's used by long-lived components
+//(store, leader election and audit log) so they follow the Kibana API client when configuration is reloaded.
+type SwappableAPI struct {
+	api API
+	mx  sync.RWMutex
+}
+
+//NewSwappableAPI Constructor
+func NewSwappableAPI(api API) *SwappableAPI {
+	return &SwappableAPI{api: api}
+}
+
+//Swap Forward calls made after it returns to api. (calls in flight finish with the previous API)
+func (s *SwappableAPI) Swap(api API) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.api = api
+}
+
+func (s *SwappableAPI) current() API {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.api
+}
+
+//Info Forward to current API
+func (s *SwappableAPI) Info(ctx context.Context) (Info, error) {
+	return s.current().Info(ctx)
+}
+
+//ClusterStateVersion Forward to current API
+func (s *SwappableAPI) ClusterStateVersion(ctx context.Context) (int64, error) {
+	return s.current().ClusterStateVersion(ctx)
+}
+
+//Indices Forward to current API
+func (s *SwappableAPI) Indices(ctx context.Context, filter string) ([]Index, error) {
+	return s.current().Indices(ctx, filter)
+}
+
+//Fields Forward to current API
+func (s *SwappableAPI) Fields(ctx context.Context, pattern string) ([]Field, error) {
+	return s.current().Fields(ctx, pattern)
+}
+
+//IndexPatterns Forward to current API
+func (s *SwappableAPI) IndexPatterns(ctx context.Context, filter string, fields []string) ([]IndexPattern, error) {
+	return s.current().IndexPatterns(ctx, filter, fields)
+}
+
+//GetIndexPattern Forward to current API
+func (s *SwappableAPI) GetIndexPattern(ctx context.Context, id string) (IndexPattern, bool, error) {
+	return s.current().GetIndexPattern(ctx, id)
+}
+
+//BulkCreateIndexPattern Forward to current API
+func (s *SwappableAPI) BulkCreateIndexPattern(ctx context.Context, indexPattern []IndexPattern) error {
+	return s.current().BulkCreateIndexPattern(ctx, indexPattern)
+}
+
+//DeleteIndexPattern Forward to current API
+func (s *SwappableAPI) DeleteIndexPattern(ctx context.Context, id string) error {
+	return s.current().DeleteIndexPattern(ctx, id)
+}
+
+//FindSavedObjects Forward to current API
+func (s *SwappableAPI) FindSavedObjects(ctx context.Context, query SavedObjectsQuery) ([]SavedObject, error) {
+	return s.current().FindSavedObjects(ctx, query)
+}
+
+//GetSavedObject Forward to current API
+func (s *SwappableAPI) GetSavedObject(ctx context.Context, objectType, id string) (SavedObject, bool, error) {
+	return s.current().GetSavedObject(ctx, objectType, id)
+}
+
+//UpdateSavedObject Forward to current API
+func (s *SwappableAPI) UpdateSavedObject(ctx context.Context, object SavedObject) error {
+	return s.current().UpdateSavedObject(ctx, object)
+}
+
+//ExportSavedObjects Forward to current API
+func (s *SwappableAPI) ExportSavedObjects(ctx context.Context, types []string) ([]byte, error) {
+	return s.current().ExportSavedObjects(ctx, types)
+}
+
+//ImportSavedObjects Forward to current API
+func (s *SwappableAPI) ImportSavedObjects(ctx context.Context, ndjson []byte, overwrite bool) (ImportResult, error) {
+	return s.current().ImportSavedObjects(ctx, ndjson, overwrite)
+}
+
+//CreateRule Forward to current API
+func (s *SwappableAPI) CreateRule(ctx context.Context, id string, rule json.RawMessage) (bool, error) {
+	return s.current().CreateRule(ctx, id, rule)
+}
+
+//DeleteRule Forward to current API
+func (s *SwappableAPI) DeleteRule(ctx context.Context, id string) error {
+	return s.current().DeleteRule(ctx, id)
+}
+
+//DefaultIndexPattern Forward to current API
+func (s *SwappableAPI) DefaultIndexPattern(ctx context.Context) (string, error) {
+	return s.current().DefaultIndexPattern(ctx)
+}
+
+//SetDefaultIndexPattern Forward to current API
+func (s *SwappableAPI) SetDefaultIndexPattern(ctx context.Context, id string) error {
+	return s.current().SetDefaultIndexPattern(ctx, id)
+}
+
+//GetDocument Forward to current API
+func (s *SwappableAPI) GetDocument(ctx context.Context, index, id string, doc interface{}) (*DocumentVersion, error) {
+	return s.current().GetDocument(ctx, index, id, doc)
+}
+
+//IndexDocument Forward to current API
+func (s *SwappableAPI) IndexDocument(ctx context.Context, index, id string, doc interface{}, version *DocumentVersion) error {
+	return s.current().IndexDocument(ctx, index, id, doc, version)
+}
+
+//SearchDocuments Forward to current API
+func (s *SwappableAPI) SearchDocuments(ctx context.Context, index string, query interface{}) ([]json.RawMessage, error) {
+	return s.current().SearchDocuments(ctx, index, query)
+}
@@ -1,20 +1,66 @@
 package kibana
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 
 	"github.com/Masterminds/semver/v3"
+	"github.com/sherifabdlnaby/rubban/config"
 )
 
 //API is an interface for supporting multiple Kibana APIs
 type API interface {
 	Info(ctx context.Context) (Info, error)
 
+	ClusterStateVersion(ctx context.Context) (int64, error)
+
 	Indices(ctx context.Context, filter string) ([]Index, error)
 
+	Fields(ctx context.Context, pattern string) ([]Field, error)
+
 	IndexPatterns(ctx context.Context, filter string, fields []string) ([]IndexPattern, error)
 
+	GetIndexPattern(ctx context.Context, id string) (IndexPattern, bool, error)
+
 	BulkCreateIndexPattern(ctx context.Context, indexPattern []IndexPattern) error
+
+	DeleteIndexPattern(ctx context.Context, id string) error
+
+	FindSavedObjects(ctx context.Context, query SavedObjectsQuery) ([]SavedObject, error)
+
+	GetSavedObject(ctx context.Context, objectType, id string) (SavedObject, bool, error)
+
+	UpdateSavedObject(ctx context.Context, object SavedObject) error
+
+	ExportSavedObjects(ctx context.Context, types []string) ([]byte, error)
+
+	ImportSavedObjects(ctx context.Context, ndjson []byte, overwrite bool) (ImportResult, error)
+
+	CreateRule(ctx context.Context, id string, rule json.RawMessage) (bool, error)
+
+	DeleteRule(ctx context.Context, id string) error
+
+	DefaultIndexPattern(ctx context.Context) (string, error)
+
+	SetDefaultIndexPattern(ctx context.Context, id string) error
+
+	GetDocument(ctx context.Context, index, id string, doc interface{}) (*DocumentVersion, error)
+
+	IndexDocument(ctx context.Context, index, id string, doc interface{}, version *DocumentVersion) error
+
+	SearchDocuments(ctx context.Context, index string, query interface{}) ([]json.RawMessage, error)
+}
+
+//ErrConflict is returned when a document was changed since it was read.
+var ErrConflict = errors.New("document version conflict")
+
+//DocumentVersion of an Elasticsearch Document, used for optimistic concurrency control.
+type DocumentVersion struct {
+	SeqNo       int64 `json:"_seq_no"`
+	PrimaryTerm int64 `json:"_primary_term"`
 }
 
 //Info for Json Unmarshalling API Response
@@ -39,11 +85,108 @@ type Index struct {
 	Name string `json:"index"`
 }
 
+//Field for Json Unmarshalling API Response
+type Field struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
 //IndexPattern for Json Unmarshalling API Response
 type IndexPattern struct {
-	ID            string `json:"id,omitempty"`
-	Title         string `json:"title"`
-	TimeFieldName string `json:"timeFieldName"`
+	ID              string `json:"id,omitempty"`
+	Title           string `json:"title"`
+	Name            string `json:"name,omitempty"` // Kibana 8.0 and greater versions only
+	TimeFieldName   string `json:"timeFieldName"`
+	FieldFormatMap  string `json:"fieldFormatMap,omitempty"`
+	SourceFilters   string `json:"sourceFilters,omitempty"`
+	RuntimeFieldMap string `json:"runtimeFieldMap,omitempty"`
+	AllowNoIndex    bool   `json:"allowNoIndex,omitempty"`
+}
+
+//SavedIndexPattern for Json Unmarshalling API Response
+type SavedIndexPattern struct {
+	Type       string       `json:"type"`
+	ID         string       `json:"id"`
+	Version    string       `json:"version"`
+	Attributes IndexPattern `json:"attributes"`
+}
+
+//SavedObject for Json Unmarshalling API Response
+type SavedObject struct {
+	Type             string            `json:"type"`
+	ID               string            `json:"id"`
+	Attributes       json.RawMessage   `json:"attributes,omitempty"`
+	References       []Reference       `json:"references"`
+	MigrationVersion map[string]string `json:"migrationVersion,omitempty"`
+}
+
+//Title Return title attribute of a SavedObject (empty if it has none)
+func (s SavedObject) Title() string {
+	attributes := struct {
+		Title string `json:"title"`
+	}{}
+	_ = json.Unmarshal(s.Attributes, &attributes)
+	return attributes.Title
+}
+
+//DecodeSavedObjects Decode SavedObjects from NDJSON (as exported by Kibana), skipping the export details line.
+func DecodeSavedObjects(ndjson []byte) ([]SavedObject, error) {
+	objects := make([]SavedObject, 0)
+	for i, line := range bytes.Split(ndjson, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		object := SavedObject{}
+		if err := json.Unmarshal(line, &object); err != nil {
+			return nil, fmt.Errorf("failed to decode saved object at line %d, error: %s", i+1, err.Error())
+		}
+
+		// Export details have no type
+		if object.Type == "" {
+			continue
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+//Reference of a SavedObject to another SavedObject
+type Reference struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+//SavedObjectsQuery to find SavedObjects of Types, optionally only ones referencing HasReference.
+type SavedObjectsQuery struct {
+	Types        []string
+	HasReference *Reference
+}
+
+//ImportResult for Json Unmarshalling API Response
+type ImportResult struct {
+	Success      bool          `json:"success"`
+	SuccessCount int           `json:"successCount"`
+	Errors       []ImportError `json:"errors,omitempty"`
+}
+
+//ImportError of a SavedObject that failed to import
+type ImportError struct {
+	Type  string `json:"type"`
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Error struct {
+		Type string `json:"type"`
+	} `json:"error"`
+}
+
+//Settings for Json Unmarshalling API Response
+type Settings struct {
+	Settings map[string]struct {
+		UserValue interface{} `json:"userValue"`
+	} `json:"settings"`
 }
 
 //BulkIndexPattern for Json Unmarshalling API Response
@@ -53,6 +196,14 @@ type BulkIndexPattern struct {
 	ID         string       `json:"id,omitempty"`
 }
 
+//SavedObjectsPage for Json Unmarshalling API Response
+type SavedObjectsPage struct {
+	Page         int           `json:"page"`
+	PerPage      int           `json:"per_page"`
+	Total        int           `json:"total"`
+	SavedObjects []SavedObject `json:"saved_objects"`
+}
+
 //IndexPatternPage for Json Unmarshalling API Response
 type IndexPatternPage struct {
 	Page         int `json:"page"`
@@ -65,3 +216,37 @@ type IndexPatternPage struct {
 		Attributes IndexPattern `json:"attributes"`
 	} `json:"saved_objects"`
 }
+
+//NewIndexPatternAttributes Build index pattern attributes from config, Kibana expects fieldFormatMap, sourceFilters and
+//runtimeFieldMap as JSON encoded strings. (attributes are validated when loading config)
+func NewIndexPatternAttributes(attributes config.IndexPatternAttributes) IndexPattern {
+	indexPattern := IndexPattern{
+		AllowNoIndex: attributes.AllowNoIndex,
+	}
+
+	if len(attributes.FieldFormatMap) > 0 {
+		indexPattern.FieldFormatMap = mustMarshal(attributes.FieldFormatMap)
+	}
+
+	if len(attributes.SourceFilters) > 0 {
+		sourceFilters := make([]map[string]string, 0, len(attributes.SourceFilters))
+		for _, filter := range attributes.SourceFilters {
+			sourceFilters = append(sourceFilters, map[string]string{"value": filter})
+		}
+		indexPattern.SourceFilters = mustMarshal(sourceFilters)
+	}
+
+	if len(attributes.RuntimeFieldMap) > 0 {
+		indexPattern.RuntimeFieldMap = mustMarshal(attributes.RuntimeFieldMap)
+	}
+
+	return indexPattern
+}
+
+func mustMarshal(v interface{}) string {
+	buff, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return string(buff)
+}
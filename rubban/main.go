@@ -28,12 +28,23 @@ func Main() {
 	// Start
 	go rubban.Start()
 
+	// Reload Configuration on SIGHUP
+	go onReloadSignal(rubban.Reload)
+
 	// Wait to Shutdown
 	<-shutdownSignal
 
 	os.Exit(0)
 }
 
+func onReloadSignal(callback func()) {
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGHUP)
+	for range signalChan {
+		callback()
+	}
+}
+
 func onTerminationSignal(callback func()) {
 	// Signal Channels
 	signalChan := make(chan os.Signal, 1)
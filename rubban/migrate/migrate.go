@@ -0,0 +1,142 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sherifabdlnaby/rubban/log"
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+	"github.com/sherifabdlnaby/rubban/rubban/store"
+	"github.com/sherifabdlnaby/rubban/rubban/utils"
+)
+
+//Migrate copies index patterns from a Kibana to another, keeping their IDs and attributes (time field, field formats,
+//source filters, fields, etc).
+type Migrate struct {
+	from        kibana.API
+	to          kibana.API
+	filter      string
+	titlePrefix string
+	overwrite   bool
+	log         log.Logger
+}
+
+//Options of a Migrate
+type Options struct {
+	// Filter Copy only index patterns whose title matches filter (support wildcards)
+	Filter string
+
+	// TitlePrefix Prefix every pattern of copied index patterns' titles (e.g a cross cluster search "cluster:" prefix)
+	TitlePrefix string
+
+	// Overwrite index patterns with the same ID in the destination Kibana
+	Overwrite bool
+}
+
+//NewMigrate Constructor
+func NewMigrate(from, to kibana.API, options Options, log log.Logger) *Migrate {
+	if options.Filter == "" {
+		options.Filter = "*"
+	}
+	return &Migrate{
+		from:        from,
+		to:          to,
+		filter:      options.Filter,
+		titlePrefix: options.TitlePrefix,
+		overwrite:   options.Overwrite,
+		log:         log,
+	}
+}
+
+//IndexPatterns Return index patterns to copy from the source Kibana, with their titles rewritten, sorted by title.
+func (m *Migrate) IndexPatterns(ctx context.Context) ([]kibana.SavedObject, error) {
+	objects, err := m.from.FindSavedObjects(ctx, kibana.SavedObjectsQuery{Types: []string{"index-pattern"}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get index patterns from source Kibana, error: %s", err.Error())
+	}
+
+	indexPatterns := make([]kibana.SavedObject, 0, len(objects))
+	for _, object := range objects {
+		if !utils.MatchGlob(m.filter, object.Title()) {
+			continue
+		}
+
+		if m.titlePrefix != "" {
+			attributes := make(map[string]interface{})
+			if err := json.Unmarshal(object.Attributes, &attributes); err != nil {
+				return nil, fmt.Errorf("failed to decode index pattern [%s], error: %s", object.ID, err.Error())
+			}
+			attributes["title"] = PrefixTitle(object.Title(), m.titlePrefix)
+			object.Attributes, err = json.Marshal(attributes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to JSON marshaling index pattern [%s]", object.ID)
+			}
+		}
+
+		if object.References == nil {
+			object.References = []kibana.Reference{}
+		}
+		indexPatterns = append(indexPatterns, object)
+	}
+
+	sort.Slice(indexPatterns, func(i, j int) bool { return indexPatterns[i].Title() < indexPatterns[j].Title() })
+	return indexPatterns, nil
+}
+
+//Copy Import index patterns to the destination Kibana, index patterns with the same ID already there fail unless
+//overwriting.
+func (m *Migrate) Copy(ctx context.Context, indexPatterns []kibana.SavedObject) (kibana.ImportResult, error) {
+	if len(indexPatterns) == 0 {
+		return kibana.ImportResult{Success: true}, nil
+	}
+
+	ndjson := &bytes.Buffer{}
+	encoder := json.NewEncoder(ndjson)
+	for _, indexPattern := range indexPatterns {
+		if err := encoder.Encode(indexPattern); err != nil {
+			return kibana.ImportResult{}, fmt.Errorf("failed to JSON marshaling index pattern [%s]", indexPattern.ID)
+		}
+	}
+
+	result, err := m.to.ImportSavedObjects(ctx, ndjson.Bytes(), m.overwrite)
+	if err != nil {
+		return result, err
+	}
+
+	failed := make(map[string]bool)
+	for _, importError := range result.Errors {
+		failed[importError.ID] = true
+		m.log.Warnw("Failed to copy index pattern", "id", importError.ID, "title", importError.Title, "error", importError.Error.Type)
+	}
+
+	for _, indexPattern := range indexPatterns {
+		if !failed[indexPattern.ID] {
+			store.Record(ctx, store.Change{Action: store.ActionCreated, Type: "index-pattern", ID: indexPattern.ID, Title: indexPattern.Title()})
+		}
+	}
+
+	return result, nil
+}
+
+//PrefixTitle Add prefix to every pattern of an index pattern title (including exclusions), patterns that already
+//target a remote cluster are kept as is. e.g "logs-*,-logs-old" with prefix "remote:" is "remote:logs-*,-remote:logs-old".
+func PrefixTitle(title, prefix string) string {
+	patterns := strings.Split(title, ",")
+	for i, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if strings.Contains(pattern, ":") {
+			patterns[i] = pattern
+			continue
+		}
+		if strings.HasPrefix(pattern, "-") {
+			patterns[i] = "-" + prefix + strings.TrimPrefix(pattern, "-")
+		} else {
+			patterns[i] = prefix + pattern
+		}
+	}
+	return strings.Join(patterns, ",")
+}
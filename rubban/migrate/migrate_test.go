@@ -0,0 +1,75 @@
+package migrate
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/sherifabdlnaby/rubban/log"
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+)
+
+type mockAPI struct {
+	kibana.API
+	objects   []kibana.SavedObject
+	imported  []kibana.SavedObject
+	overwrite bool
+}
+
+func (m *mockAPI) FindSavedObjects(ctx context.Context, query kibana.SavedObjectsQuery) ([]kibana.SavedObject, error) {
+	return m.objects, nil
+}
+
+func (m *mockAPI) ImportSavedObjects(ctx context.Context, ndjson []byte, overwrite bool) (kibana.ImportResult, error) {
+	objects, err := kibana.DecodeSavedObjects(ndjson)
+	m.imported, m.overwrite = objects, overwrite
+	return kibana.ImportResult{Success: true, SuccessCount: len(objects)}, err
+}
+
+func TestMigrate(t *testing.T) {
+	from := &mockAPI{objects: []kibana.SavedObject{
+		{Type: "index-pattern", ID: "logs", Attributes: json.RawMessage(`{"title":"logs-*,-logs-old","timeFieldName":"@timestamp","fieldFormatMap":"{\"bytes\":{\"id\":\"bytes\"}}"}`)},
+		{Type: "index-pattern", ID: "metrics", Attributes: json.RawMessage(`{"title":"metrics-*"}`)},
+	}}
+	to := &mockAPI{}
+
+	m := NewMigrate(from, to, Options{Filter: "logs-*", TitlePrefix: "remote:", Overwrite: true}, log.Default())
+
+	indexPatterns, err := m.IndexPatterns(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := m.Copy(context.Background(), indexPatterns)
+	if err != nil || result.SuccessCount != 1 || !to.overwrite {
+		t.Fatalf("Unexpected result %+v, %v", result, err)
+	}
+
+	copied := to.imported[0]
+	if copied.ID != "logs" || copied.Title() != "remote:logs-*,-remote:logs-old" {
+		t.Errorf("Expected logs to be copied with a prefixed title, got %s %s", copied.ID, copied.Title())
+	}
+
+	attributes := struct {
+		TimeFieldName  string `json:"timeFieldName"`
+		FieldFormatMap string `json:"fieldFormatMap"`
+	}{}
+	_ = json.Unmarshal(copied.Attributes, &attributes)
+	if attributes.TimeFieldName != "@timestamp" || attributes.FieldFormatMap != `{"bytes":{"id":"bytes"}}` {
+		t.Errorf("Expected attributes to be copied as is, got %s", copied.Attributes)
+	}
+}
+
+func TestPrefixTitle(t *testing.T) {
+	for title, expected := range map[string]string{
+		"logs-*":                 "remote:logs-*",
+		"logs-*, metrics-*":      "remote:logs-*,remote:metrics-*",
+		"logs-*,-logs-old":       "remote:logs-*,-remote:logs-old",
+		"logs-*,other:logs-*":    "remote:logs-*,other:logs-*",
+		"local:logs-*,*:metrics": "local:logs-*,*:metrics",
+	} {
+		if actual := PrefixTitle(title, "remote:"); actual != expected {
+			t.Errorf("Expected %s to be prefixed as %s, got %s", title, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sherifabdlnaby/rubban/config"
+	"github.com/sherifabdlnaby/rubban/log"
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+	"github.com/sherifabdlnaby/rubban/rubban/store"
+)
+
+//Events notifiers can subscribe to
+const (
+	EventChanges     = "changes"
+	EventFailures    = "failures"
+	EventUnreachable = "unreachable"
+)
+
+//Notification of an event in a task run.
+type Notification struct {
+	Event      string    `json:"event"`
+	Task       string    `json:"task"`
+	Message    string    `json:"message"`
+	Run        store.Run `json:"run"`
+	Suppressed int       `json:"suppressed,omitempty"`
+}
+
+//Sender sends notifications to an output.
+type Sender interface {
+	Send(ctx context.Context, notification Notification) error
+}
+
+// notifier is an output with the tasks and events it's subscribed to.
+type notifier struct {
+	name       string
+	sender     Sender
+	tasks      map[string]bool
+	events     map[string]bool
+	lastSent   map[string]time.Time
+	suppressed map[string]int
+}
+
+//Notifier sends notifications of task runs to configured outputs, repeated notifications of the same event and task are
+//sent at most once per rate limit.
+type Notifier struct {
+	notifiers []*notifier
+	rateLimit time.Duration
+	api       kibana.API
+	logger    log.Logger
+	mx        sync.Mutex
+	wg        sync.WaitGroup
+}
+
+//NewNotifier Constructor
+func NewNotifier(config config.Notify, api kibana.API, logger log.Logger) *Notifier {
+	notifiers := make([]*notifier, 0, len(config.Notifiers))
+	for _, notifierConfig := range config.Notifiers {
+		notifiers = append(notifiers, newNotifier(notifierConfig))
+	}
+
+	return &Notifier{
+		notifiers: notifiers,
+		rateLimit: config.RateLimit,
+		api:       api,
+		logger:    logger,
+	}
+}
+
+func newNotifier(config config.Notifier) *notifier {
+	var sender Sender
+	switch config.Type {
+	case "webhook":
+		sender = newWebhook(config.URL)
+	case "slack":
+		sender = newSlack(config.URL)
+	case "smtp":
+		sender = newSMTP(config.SMTP)
+	}
+
+	tasks := make(map[string]bool)
+	for _, task := range config.Tasks {
+		tasks[normalize(task)] = true
+	}
+
+	// Subscribe to all events if none is set
+	events := make(map[string]bool)
+	for _, event := range config.Events {
+		events[event] = true
+	}
+	if len(events) == 0 {
+		events = map[string]bool{EventChanges: true, EventFailures: true, EventUnreachable: true}
+	}
+
+	return &notifier{
+		name:       config.Type,
+		sender:     sender,
+		tasks:      tasks,
+		events:     events,
+		lastSent:   make(map[string]time.Time),
+		suppressed: make(map[string]int),
+	}
+}
+
+//Notify Send notifications of a finished run to subscribed outputs (Non Blocking)
+func (n *Notifier) Notify(run store.Run) {
+	if len(run.Changes) == 0 && len(run.Errors) == 0 {
+		return
+	}
+
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		for _, notification := range n.notifications(ctx, run) {
+			for _, notifier := range n.notifiers {
+				if !notifier.subscribed(notification) {
+					continue
+				}
+
+				notification := notification
+				var allowed bool
+				allowed, notification.Suppressed = n.allow(notifier, notification)
+				if !allowed {
+					n.logger.Debugw("Notification suppressed by rate limit", "notifier", notifier.name, "event", notification.Event, "task", notification.Task)
+					continue
+				}
+
+				err := notifier.sender.Send(ctx, notification)
+				if err != nil {
+					n.logger.Warnw("Failed to send notification", "notifier", notifier.name, "event", notification.Event, "error", err.Error())
+				}
+			}
+		}
+	}()
+}
+
+//Close Wait for notifications being sent.
+func (n *Notifier) Close() {
+	n.wg.Wait()
+}
+
+// notifications Return notifications of a run, a run with errors is a failure; or unreachable if Kibana doesn't respond.
+func (n *Notifier) notifications(ctx context.Context, run store.Run) []Notification {
+	notifications := make([]Notification, 0)
+
+	if len(run.Changes) > 0 {
+		lines := make([]string, 0, len(run.Changes))
+		for _, change := range run.Changes {
+			lines = append(lines, fmt.Sprintf("- %s %s %s", change.Action, change.Type, change.Title))
+		}
+		notifications = append(notifications, Notification{
+			Event:   EventChanges,
+			Task:    run.Task,
+			Message: fmt.Sprintf("Rubban: %s made %d change(s)\n%s", run.Task, len(run.Changes), strings.Join(lines, "\n")),
+			Run:     run,
+		})
+	}
+
+	if len(run.Errors) > 0 {
+		event, message := EventFailures, fmt.Sprintf("Rubban: %s failed with %d error(s)", run.Task, len(run.Errors))
+		if _, err := n.api.Info(ctx); err != nil {
+			event, message = EventUnreachable, fmt.Sprintf("Rubban: Kibana is unreachable, %s failed", run.Task)
+		}
+		notifications = append(notifications, Notification{
+			Event:   event,
+			Task:    run.Task,
+			Message: message + "\n- " + strings.Join(run.Errors, "\n- "),
+			Run:     run,
+		})
+	}
+
+	return notifications
+}
+
+// allow Return true if notification is not rate limited, and the count of similar notifications suppressed since the
+// last one sent.
+func (n *Notifier) allow(notifier *notifier, notification Notification) (bool, int) {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	key := notification.Event + "/" + notification.Task
+	if last, ok := notifier.lastSent[key]; ok && time.Since(last) < n.rateLimit {
+		notifier.suppressed[key]++
+		return false, 0
+	}
+
+	suppressed := notifier.suppressed[key]
+	notifier.lastSent[key] = time.Now()
+	notifier.suppressed[key] = 0
+	return true, suppressed
+}
+
+func (n *notifier) subscribed(notification Notification) bool {
+	if !n.events[notification.Event] {
+		return false
+	}
+	return len(n.tasks) == 0 || n.tasks[normalize(notification.Task)]
+}
+
+// normalize task names so that both "Auto Index Pattern" and "autoIndexPattern" match the same task.
+func normalize(task string) string {
+	return strings.ToLower(strings.Replace(task, " ", "", -1))
+}
+
+// text Return notification's message as text, noting suppressed notifications.
+func text(notification Notification) string {
+	if notification.Suppressed > 0 {
+		return fmt.Sprintf("%s\n(%d similar notification(s) were suppressed)", notification.Message, notification.Suppressed)
+	}
+	return notification.Message
+}
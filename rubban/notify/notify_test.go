@@ -0,0 +1,215 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sherifabdlnaby/rubban/config"
+	"github.com/sherifabdlnaby/rubban/log"
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+	"github.com/sherifabdlnaby/rubban/rubban/store"
+)
+
+type mockAPI struct {
+	kibana.API
+	unreachable bool
+}
+
+func (m *mockAPI) Info(ctx context.Context) (kibana.Info, error) {
+	if m.unreachable {
+		return kibana.Info{}, errors.New("connection refused")
+	}
+	return kibana.Info{}, nil
+}
+
+type mockSender struct {
+	notifications []Notification
+	mx            sync.Mutex
+}
+
+func (m *mockSender) Send(ctx context.Context, notification Notification) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.notifications = append(m.notifications, notification)
+	return nil
+}
+
+func newTestNotifier(api kibana.API, notifiers ...config.Notifier) (*Notifier, []*mockSender) {
+	n := NewNotifier(config.Notify{RateLimit: time.Hour, Notifiers: notifiers}, api, log.Default())
+	senders := make([]*mockSender, 0)
+	for _, notifier := range n.notifiers {
+		sender := &mockSender{}
+		notifier.sender = sender
+		senders = append(senders, sender)
+	}
+	return n, senders
+}
+
+func TestNotifierEvents(t *testing.T) {
+	api := &mockAPI{}
+	n, senders := newTestNotifier(api,
+		config.Notifier{Type: "webhook"},
+		config.Notifier{Type: "slack", Tasks: []string{"refreshIndexPattern"}, Events: []string{EventFailures}},
+	)
+
+	changed := store.Run{Task: "Auto Index Pattern", Changes: []store.Change{{Action: store.ActionCreated, Type: "index-pattern", Title: "logs-*"}}}
+	failed := store.Run{Task: "Refresh Index Pattern", Errors: []string{"failed"}}
+
+	n.Notify(changed)
+	n.Notify(store.Run{Task: "Auto Index Pattern"})
+	n.Close()
+	n.Notify(failed)
+	n.Close()
+	api.unreachable = true
+	n.Notify(store.Run{Task: "Auto Index Pattern", Errors: []string{"connection refused"}})
+	n.Close()
+
+	expected := [][]string{
+		{EventChanges + "/Auto Index Pattern", EventFailures + "/Refresh Index Pattern", EventUnreachable + "/Auto Index Pattern"},
+		{EventFailures + "/Refresh Index Pattern"},
+	}
+
+	for i, sender := range senders {
+		if len(sender.notifications) != len(expected[i]) {
+			t.Fatalf("Expected notifier %d to send %v, got %v", i, expected[i], sender.notifications)
+		}
+		for j, notification := range sender.notifications {
+			if notification.Event+"/"+notification.Task != expected[i][j] {
+				t.Errorf("Expected notifier %d to send %s, got %s/%s", i, expected[i][j], notification.Event, notification.Task)
+			}
+		}
+	}
+}
+
+func TestNotifierRateLimit(t *testing.T) {
+	n, senders := newTestNotifier(&mockAPI{}, config.Notifier{Type: "webhook"})
+
+	failed := store.Run{Task: "Auto Index Pattern", Errors: []string{"failed"}}
+	changed := store.Run{Task: "Auto Index Pattern", Changes: []store.Change{{Action: store.ActionCreated, Type: "index-pattern", Title: "logs-*"}}}
+	for i := 0; i < 3; i++ {
+		n.Notify(failed)
+		n.Notify(changed)
+		n.Close()
+	}
+
+	// Every event is rate limited
+	failures, changes := 0, 0
+	for _, notification := range senders[0].notifications {
+		switch notification.Event {
+		case EventFailures:
+			failures++
+		case EventChanges:
+			changes++
+		}
+	}
+
+	if failures != 1 || changes != 1 {
+		t.Errorf("Expected 1 failure and 1 changes notifications, got %d and %d", failures, changes)
+	}
+
+	// Suppressed notifications are counted in the next one sent.
+	n.notifiers[0].lastSent = map[string]time.Time{}
+	n.Notify(failed)
+	n.Close()
+
+	last := senders[0].notifications[len(senders[0].notifications)-1]
+	if last.Suppressed != 2 {
+		t.Errorf("Expected 2 suppressed notifications, got %d", last.Suppressed)
+	}
+}
+
+// newSMTPServer start an SMTP server accepting a single connection, it answers every command unless hang is true, and
+// the received message is sent to the returned channel.
+func newSMTPServer(t *testing.T, hang bool) (config.SMTP, chan string, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := make(chan string, 1)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if hang {
+			_, _ = bufio.NewReader(conn).ReadString('\n')
+			return
+		}
+
+		reader := bufio.NewReader(conn)
+		_, _ = conn.Write([]byte("220 localhost ESMTP\r\n"))
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch command := strings.ToUpper(strings.Fields(line)[0]); command {
+			case "EHLO", "HELO", "MAIL", "RCPT":
+				_, _ = conn.Write([]byte("250 OK\r\n"))
+			case "DATA":
+				_, _ = conn.Write([]byte("354 Go ahead\r\n"))
+				message := strings.Builder{}
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					message.WriteString(line)
+				}
+				messages <- message.String()
+				_, _ = conn.Write([]byte("250 OK\r\n"))
+			case "QUIT":
+				_, _ = conn.Write([]byte("221 Bye\r\n"))
+				return
+			default:
+				_, _ = conn.Write([]byte("502 Not implemented\r\n"))
+			}
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	return config.SMTP{Host: host, Port: portNumber, From: "rubban@example.com", To: []string{"ops@example.com"}},
+		messages, func() { _ = listener.Close() }
+}
+
+func TestSMTP(t *testing.T) {
+	smtpConfig, messages, closeServer := newSMTPServer(t, false)
+	defer closeServer()
+
+	err := newSMTP(smtpConfig).Send(context.Background(), Notification{Event: EventFailures, Message: "Rubban: Backup failed\n- disk full"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := <-messages
+	if !strings.Contains(message, "Subject: Rubban: Backup failed\r\n") || !strings.Contains(message, "- disk full") {
+		t.Errorf("Unexpected message %q", message)
+	}
+}
+
+func TestSMTPHung(t *testing.T) {
+	smtpConfig, _, closeServer := newSMTPServer(t, true)
+	defer closeServer()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	err := newSMTP(smtpConfig).Send(ctx, Notification{Event: EventFailures, Message: "Rubban: Backup failed"})
+	if err == nil {
+		t.Fatalf("Expected sending to a hung SMTP server to fail")
+	}
+	if time.Since(started) > 5*time.Second {
+		t.Errorf("Expected sending to a hung SMTP server to fail at the context's deadline, took %s", time.Since(started))
+	}
+}
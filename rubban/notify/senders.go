@@ -0,0 +1,150 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sherifabdlnaby/rubban/config"
+)
+
+//webhook posts notifications as JSON to a URL.
+type webhook struct {
+	url    string
+	client *http.Client
+}
+
+func newWebhook(url string) *webhook {
+	return &webhook{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (w *webhook) Send(ctx context.Context, notification Notification) error {
+	return postJSON(ctx, w.client, w.url, notification)
+}
+
+//slack posts notifications to a Slack (or Slack compatible) incoming webhook.
+type slack struct {
+	url    string
+	client *http.Client
+}
+
+func newSlack(url string) *slack {
+	return &slack{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (s *slack) Send(ctx context.Context, notification Notification) error {
+	return postJSON(ctx, s.client, s.url, map[string]string{"text": text(notification)})
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, body interface{}) error {
+	buff, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(buff))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to post notification, error: %s", resp.Status)
+	}
+	return nil
+}
+
+//smtpSender sends notifications by email.
+type smtpSender struct {
+	config config.SMTP
+}
+
+// smtpTimeout bounds sending an email when the context has no deadline.
+const smtpTimeout = 30 * time.Second
+
+func newSMTP(config config.SMTP) *smtpSender {
+	return &smtpSender{config: config}
+}
+
+//Send Send notification by email, the whole SMTP exchange is bound by ctx's deadline (so a hung SMTP server can't block
+//the notifier).
+func (s *smtpSender) Send(ctx context.Context, notification Notification) error {
+	body := text(notification)
+	subject := strings.SplitN(body, "\n", 2)[0]
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		s.config.From, strings.Join(s.config.To, ", "), subject, strings.Replace(body, "\n", "\r\n", -1))
+
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port)))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// Same as smtp.SendMail
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.config.Host}); err != nil {
+			return err
+		}
+	}
+
+	if s.config.Username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return fmt.Errorf("smtp server doesn't support AUTH")
+		}
+		if err := client.Auth(smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(s.config.From); err != nil {
+		return err
+	}
+	for _, to := range s.config.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write([]byte(msg)); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
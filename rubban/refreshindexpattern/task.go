@@ -7,11 +7,14 @@ import (
 
 	"github.com/sherifabdlnaby/gpool"
 	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+	"github.com/sherifabdlnaby/rubban/rubban/store"
 	"go.uber.org/atomic"
 )
 
 //Run Run Auto Index Pattern creation task
-func (a *RefreshIndexPattern) Run(ctx context.Context) {
+func (a *RefreshIndexPattern) Run(ctx context.Context) store.Result {
+	result := store.NewResult()
+	mx := sync.Mutex{}
 
 	// Send Requests Concurrently
 	idxPatternPool := gpool.NewPool(a.concurrency)
@@ -31,6 +34,9 @@ func (a *RefreshIndexPattern) Run(ctx context.Context) {
 				if err != nil {
 					a.log.Warnw("Failed to get index pattern...",
 						"pattern", shadowPattern, "error", err.Error())
+					mx.Lock()
+					result.AddError(err)
+					mx.Unlock()
 					return
 				}
 
@@ -57,12 +63,22 @@ func (a *RefreshIndexPattern) Run(ctx context.Context) {
 			err := a.kibana.BulkCreateIndexPattern(ctx, shadowedPatterns)
 			if err != nil {
 				a.log.Warnw("Failed to update index patterns", "error", err.Error(), "patterns", shadowedPatterns)
+				mx.Lock()
+				result.AddError(err)
+				mx.Unlock()
+				return
+			}
+			for _, pattern := range shadowedPatterns {
+				store.Record(ctx, store.Change{Action: store.ActionRefreshed, Type: "index-pattern", ID: pattern.ID, Title: pattern.Title})
 			}
 			count.Add(int32(len(shadowedPatterns)))
 		})
 	}
 	idxPatternPool.Stop()
+	result.Add(store.ActionRefreshed, int(count.Load()))
 	a.log.Info(fmt.Sprintf("Finished Updating Index Pattern(s) Fields, Updated (%d) Index Pattern.", count.Load()))
+
+	return result
 }
 
 //Name Return Task Name
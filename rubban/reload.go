@@ -0,0 +1,129 @@
+package rubban
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sherifabdlnaby/rubban/config"
+)
+
+//Reload Reload configuration, and swap Kibana API client, Tasks and scheduler with ones built from the new
+//configuration. Store, leader election and audit log are swapped to the new Kibana API client too. If the new
+//configuration is invalid (or its Kibana is unreachable) the current one is kept.
+func (r *Rubban) Reload() {
+	r.reloadMx.Lock()
+	defer r.reloadMx.Unlock()
+
+	if r.mainCtx.Err() != nil {
+		return
+	}
+
+	r.logger.Info("Reloading Configuration...")
+
+	cfg, err := config.Load("Rubban")
+	if err != nil {
+		r.logger.Errorw("Failed to reload configuration, keeping current configuration.", "error", err.Error())
+		return
+	}
+
+	r.mx.RLock()
+	current := r.state
+	r.mx.RUnlock()
+
+	if !reflect.DeepEqual(cfg.HTTP, current.config.HTTP) || !reflect.DeepEqual(cfg.Logging, current.config.Logging) ||
+		!reflect.DeepEqual(cfg.LeaderElection, current.config.LeaderElection) || !reflect.DeepEqual(cfg.Store, current.config.Store) ||
+		!reflect.DeepEqual(cfg.Audit, current.config.Audit) {
+		r.logger.Warn("Changes to http, logging, leaderElection, store and audit configuration require a restart to take effect.")
+	}
+
+	// Leader election, store and audit can't be changed without a restart.
+	cfg.LeaderElection = current.config.LeaderElection
+	cfg.Store = current.config.Store
+	cfg.Audit = current.config.Audit
+
+	newState, err := r.newState(cfg)
+	if err != nil {
+		r.logger.Errorw("Failed to apply reloaded configuration, keeping current configuration.", "error", err.Error())
+		return
+	}
+
+	// Keep paused tasks paused.
+	for _, task := range current.scheduler.Tasks() {
+		if task.Paused {
+			_ = newState.scheduler.Pause(task.Name)
+		}
+	}
+
+	// Stop current scheduler and wait for its running jobs, then swap.
+	current.scheduler.Stop()
+	current.cancel()
+	if current.notifier != nil {
+		current.notifier.Close()
+	}
+
+	r.mx.Lock()
+	r.state = newState
+	r.api.Swap(newState.client)
+	r.mx.Unlock()
+
+	r.startState(newState)
+
+	r.logger.Info("Successfully Reloaded Configuration")
+}
+
+//watchConfig Reload configuration whenever the configuration file changes. The directory is watched instead of the
+//file itself, as editors and Kubernetes ConfigMaps replace the file instead of writing to it.
+func (r *Rubban) watchConfig(ctx context.Context, file string) {
+	if file == "" {
+		return
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		r.logger.Errorw("Failed to watch configuration file", "error", err.Error())
+		return
+	}
+	defer watcher.Close()
+
+	dir, name := filepath.Split(filepath.Clean(file))
+	if dir == "" {
+		dir = "."
+	}
+
+	err = watcher.Add(dir)
+	if err != nil {
+		r.logger.Errorw("Failed to watch configuration file", "file", file, "error", err.Error())
+		return
+	}
+
+	r.logger.Infof("Watching configuration file %s for changes", file)
+
+	// Debounce, as a single save usually emits multiple events.
+	debounce := time.NewTimer(time.Hour)
+	debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			base := filepath.Base(event.Name)
+			if base == name || base == "..data" {
+				debounce.Reset(time.Second)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			r.logger.Warnw("Error watching configuration file", "error", err.Error())
+		case <-debounce.C:
+			r.Reload()
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package rubban
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sherifabdlnaby/rubban/config"
+	"github.com/sherifabdlnaby/rubban/log"
+	"github.com/sherifabdlnaby/rubban/rubban/store"
+)
+
+// newReloadTest start a fake Kibana and write configs to a config dir Rubban is loaded from, the returned function
+// writes a config (with %s replaced by the fake Kibana's host) and cleanup restores everything.
+func newReloadTest(t *testing.T) (r *Rubban, write func(yml string), host string, cleanup func()) {
+	kibana := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte(`{"version":{"number":"7.10.0"}}`))
+	}))
+
+	dir, err := ioutil.TempDir("", "rubban")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous, isSet := os.LookupEnv("RUBBAN_CONFIG_DIR")
+	_ = os.Setenv("RUBBAN_CONFIG_DIR", dir)
+
+	write = func(yml string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, "rubban.yml"), []byte(yml), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cleanup = func() {
+		r.cancel()
+		if isSet {
+			_ = os.Setenv("RUBBAN_CONFIG_DIR", previous)
+		} else {
+			_ = os.Unsetenv("RUBBAN_CONFIG_DIR")
+		}
+		_ = os.RemoveAll(dir)
+		kibana.Close()
+	}
+
+	r = New()
+	r.logger = log.Nop()
+	return r, write, kibana.URL, cleanup
+}
+
+const reloadConfig = `
+kibana:
+    host: %s
+backup:
+    enabled: %t
+    path: %s
+`
+
+func TestReload(t *testing.T) {
+	r, write, host, cleanup := newReloadTest(t)
+	defer cleanup()
+
+	write(fmt.Sprintf(reloadConfig, host, false, "./backups"))
+	cfg, err := config.Load("Rubban")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.state, err = r.newState(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A task that is running while reloading
+	started, release, finished := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	running := &mockTask{name: "Running Task", run: func(ctx context.Context) store.Result {
+		close(started)
+		<-release
+		close(finished)
+		return store.NewResult()
+	}}
+	if err := r.state.scheduler.Register("@yearly", config.TaskRun{Overlap: "delay"}, running); err != nil {
+		t.Fatal(err)
+	}
+	r.startState(r.state)
+	if err := r.state.scheduler.Trigger(running.Name()); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	initial := r.state
+
+	// Invalid configuration is kept
+	write(fmt.Sprintf(reloadConfig, host, true, "") + "autoIndexPattern:\n    enabled: true\n")
+	r.Reload()
+	if r.state != initial {
+		t.Fatalf("expected invalid configuration to be rejected")
+	}
+
+	// Configuration changing Kibana to one the API client can't be initialized for is rejected
+	unsupported := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte(`{"version":{"number":"6.8.0"}}`))
+	}))
+	defer unsupported.Close()
+	write(fmt.Sprintf(reloadConfig, unsupported.URL, true, "./backups"))
+	r.Reload()
+	if r.state != initial {
+		t.Fatalf("expected configuration changing kibana to an unsupported one to be rejected")
+	}
+
+	// Configuration using index pattern names is rejected on Kibana 7.x
+	write(fmt.Sprintf(reloadConfig, host, true, "./backups") + `autoIndexPattern:
+    enabled: true
+    generalPatterns:
+        - pattern: "logs-?-*"
+          timeFieldName: "@timestamp"
+          nameTemplate: "{{.group1}}"
+`)
+	r.Reload()
+	if r.state != initial {
+		t.Fatalf("expected configuration using index pattern names on kibana 7.x to be rejected")
+	}
+
+	// Valid configuration swaps tasks, after running tasks are drained
+	write(fmt.Sprintf(reloadConfig, host, true, "./backups"))
+	reloaded := make(chan struct{})
+	go func() {
+		r.Reload()
+		close(reloaded)
+	}()
+
+	select {
+	case <-reloaded:
+		t.Fatalf("expected reload to wait for running tasks")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	<-reloaded
+	<-finished
+
+	r.mx.RLock()
+	tasks := r.state.scheduler.Tasks()
+	swapped := r.state != initial
+	r.mx.RUnlock()
+	r.state.scheduler.Stop()
+
+	if !swapped {
+		t.Fatalf("expected state to be swapped")
+	}
+	if len(tasks) != 1 || tasks[0].Name != r.state.backup.Name() {
+		t.Errorf("expected tasks to be swapped to the reloaded ones, got %+v", tasks)
+	}
+}
+
+func TestReloadKibana(t *testing.T) {
+	r, write, host, cleanup := newReloadTest(t)
+	defer cleanup()
+
+	var requests int32
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = w.Write([]byte(`{"version":{"number":"7.10.0"}}`))
+	}))
+	defer other.Close()
+
+	write(fmt.Sprintf(reloadConfig, host, false, "./backups"))
+	cfg, err := config.Load("Rubban")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.state, err = r.newState(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.startState(r.state)
+	initial := r.state
+
+	// Configuration changing Kibana swaps the API client of tasks, and of the store, leader election and audit log.
+	write(fmt.Sprintf(reloadConfig, other.URL, false, "./backups"))
+	r.Reload()
+	defer r.state.scheduler.Stop()
+	if r.state == initial || r.state.config.Kibana.Host != other.URL {
+		t.Fatalf("expected configuration changing kibana to be applied")
+	}
+
+	before := atomic.LoadInt32(&requests)
+	if _, err := r.api.Info(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&requests) != before+1 {
+		t.Errorf("expected the store, leader election and audit log API client to be swapped to the new kibana")
+	}
+}
@@ -3,27 +3,61 @@ package rubban
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/sherifabdlnaby/rubban/config"
 	"github.com/sherifabdlnaby/rubban/log"
+	"github.com/sherifabdlnaby/rubban/rubban/audit"
 	"github.com/sherifabdlnaby/rubban/rubban/autoindexpattern"
+	"github.com/sherifabdlnaby/rubban/rubban/backup"
+	"github.com/sherifabdlnaby/rubban/rubban/consolidateindexpattern"
+	"github.com/sherifabdlnaby/rubban/rubban/defaultindexpattern"
+	"github.com/sherifabdlnaby/rubban/rubban/election"
+	"github.com/sherifabdlnaby/rubban/rubban/gitops"
 	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+	"github.com/sherifabdlnaby/rubban/rubban/notify"
 	"github.com/sherifabdlnaby/rubban/rubban/refreshindexpattern"
+	"github.com/sherifabdlnaby/rubban/rubban/server"
+	"github.com/sherifabdlnaby/rubban/rubban/store"
 )
 
 //Rubban App Structure
 type Rubban struct {
+	logger   log.Logger
+	server   *server.Server
+	elector  *election.Elector
+	elected  chan struct{}
+	store    store.Store
+	audit    audit.Log
+	api      *kibana.SwappableAPI
+	state    *state
+	mx       sync.RWMutex
+	reloadMx sync.Mutex
+	mainCtx  context.Context
+	cancel   context.CancelFunc
+}
+
+// state holds everything that is built from configuration, it's swapped as a whole when configuration is reloaded.
+type state struct {
 	config              *config.Config
-	logger              log.Logger
 	semVer              semver.Version
+	client              kibana.API
 	api                 kibana.API
-	scheduler           scheduler
+	scheduler           *scheduler
+	notifier            *notify.Notifier
+	context             context.Context
+	cancel              context.CancelFunc
 	autoIndexPattern    autoindexpattern.AutoIndexPattern
 	refreshIndexPattern refreshindexpattern.RefreshIndexPattern
-	mainCtx             context.Context
-	cancel              context.CancelFunc
+	defaultIndexPattern defaultindexpattern.DefaultIndexPattern
+	gitOps              gitops.GitOps
+	consolidate         consolidateindexpattern.ConsolidateIndexPattern
+	backup              backup.Backup
 }
 
 //New Create new App structure
@@ -37,36 +71,45 @@ var errFailedToInitialize = fmt.Errorf("failed to Initialize application")
 //Initialize Initialize Application after Loading Configuration
 func (r *Rubban) Initialize() error {
 
-	var err error
-
 	// Load config
-	r.config, err = config.Load("Rubban")
+	cfg, err := config.Load("Rubban")
 	if err != nil {
 		r.logger.Fatalw("Failed to load configuration.", "error", err)
 		os.Exit(1)
 	}
 
 	// Init logger
-	r.logger = log.NewZapLoggerImpl("Rubban", r.config.Logging)
+	r.logger = log.NewZapLoggerImpl("Rubban", cfg.Logging)
 
 	r.logger.Info("Successfully Loaded Configuration")
 
-	// Create scheduler
-	r.scheduler = *newScheduler(r.mainCtx, r.logger.Extend("scheduler"))
-
-	// Init Kibana API client
-	err = r.initKibanaClient(r.mainCtx)
+	// Init Kibana API client, Tasks, and scheduler
+	r.state, err = r.newState(cfg)
 	if err != nil {
-		r.logger.Fatalw("Failed to initialize Kibana API", "error", err)
+		r.logger.Fatalw("Failed to initialize Rubban", "error", err)
 	}
 
-	// Init Tasks
-	r.initTasks()
+	// Init Store (Scheduler will save runs once it's set)
+	if cfg.Store.Enabled {
+		r.store, err = store.NewStore(cfg.Store, r.api)
+		if err != nil {
+			r.logger.Fatalw("Failed to initialize store", "error", err)
+		}
+		r.state.scheduler.store = r.store
+		r.logger.Infof("Saving task runs to %s store", cfg.Store.Backend)
+	}
 
-	// Register Tasks
-	err = r.registerTasks()
-	if err != nil {
-		r.logger.Fatalw("Failed to initialize scheduler", "error", err)
+	// Init Leader Election
+	if cfg.LeaderElection.Enabled {
+		r.elector, err = election.NewElector(cfg.LeaderElection, r.api, r.logger.Extend("election"))
+		if err != nil {
+			r.logger.Fatalw("Failed to initialize leader election", "error", err)
+		}
+	}
+
+	// Init HTTP Server
+	if cfg.HTTP.Enabled {
+		r.initServer()
 	}
 
 	return nil
@@ -77,51 +120,259 @@ func (r *Rubban) Start() {
 
 	r.logger.Infof("Starting Rubban...")
 
+	// Start Leader Election
+	if r.elector != nil {
+		r.elected = make(chan struct{})
+		go func() {
+			r.elector.Run(r.mainCtx)
+			close(r.elected)
+		}()
+	}
+
 	// Start scheduler
-	r.scheduler.Start()
+	r.mx.RLock()
+	r.startState(r.state)
+	r.state.scheduler.RunOnStart()
+	cfg := r.state.config
+	r.mx.RUnlock()
+
+	// Start HTTP Server
+	if r.server != nil {
+		r.server.Start()
+	}
+
+	// Watch Configuration File
+	if cfg.WatchConfig {
+		go r.watchConfig(r.mainCtx, cfg.File())
+	}
 }
 
 //Stop Rubban (Will wait for everything to finish)
 func (r *Rubban) Stop() {
 	r.logger.Infof("Rubban is Stopping...")
 
+	// Stop accepting requests
+	if r.server != nil {
+		r.server.Stop()
+	}
+
 	// Cancel Main Context
 	r.cancel()
 
 	// Stop and Wait for all running jobs to finish
-	r.scheduler.Stop()
+	r.reloadMx.Lock()
+	defer r.reloadMx.Unlock()
+	r.mx.RLock()
+	r.state.scheduler.Stop()
+	if r.state.notifier != nil {
+		r.state.notifier.Close()
+	}
+	r.mx.RUnlock()
+
+	// Wait for leadership to be released
+	if r.elected != nil {
+		<-r.elected
+	}
+
+	// Close Audit Log
+	if r.audit != nil {
+		if err := r.audit.Close(); err != nil {
+			r.logger.Warnw("Failed to close audit log", "error", err.Error())
+		}
+	}
+
+	// Close Store
+	if r.store != nil {
+		if err := r.store.Close(); err != nil {
+			r.logger.Warnw("Failed to close store", "error", err.Error())
+		}
+	}
 
 	r.logger.Infof("Stopped.")
 	r.logger.Infof("Goodbye <3")
 }
 
-func (r *Rubban) initTasks() {
+// newState build Kibana API client, Tasks and scheduler from config. (Doesn't start anything)
+func (r *Rubban) newState(cfg *config.Config) (*state, error) {
+	s := &state{config: cfg}
+	s.context, s.cancel = context.WithCancel(r.mainCtx)
 
-	if r.config.AutoIndexPattern.Enabled {
-		r.autoIndexPattern = *autoindexpattern.NewAutoIndexPattern(r.config.AutoIndexPattern, r.api, r.logger.Extend("autoIndexPattern"))
-		r.logger.Infof("Enabled %s, Loaded %d General Pattern(s)", r.autoIndexPattern.Name(), len(r.autoIndexPattern.GeneralPatterns))
+	// Init Kibana API client
+	err := r.initKibanaClient(s)
+	if err != nil {
+		s.cancel()
+		return nil, err
+	}
+
+	// Check configuration only uses features the Kibana version supports
+	err = checkVersion(cfg, s.semVer)
+	if err != nil {
+		s.cancel()
+		return nil, err
+	}
+
+	// Store, leader election and audit log are initialized once with an API client that is swapped to the state's
+	// client whenever a state is swapped in.
+	s.client = s.api
+	if r.api == nil {
+		r.api = kibana.NewSwappableAPI(s.client)
+	}
+
+	// Init Audit Log (once, as it can't be changed without a restart) and audit every change made through the API
+	if cfg.Audit.Enabled {
+		if r.audit == nil {
+			r.audit, err = audit.NewLog(cfg.Audit, r.api)
+			if err != nil {
+				s.cancel()
+				return nil, fmt.Errorf("failed to initialize audit log, error: %s", err.Error())
+			}
+			r.logger.Infof("Writing audit events to %s audit log", cfg.Audit.Backend)
+		}
+		s.api = audit.NewAPI(s.api, r.audit, cfg.Kibana.Space, r.logger.Extend("audit"))
 	}
 
-	if r.config.RefreshIndexPattern.Enabled {
-		r.refreshIndexPattern = *refreshindexpattern.NewRefreshIndexPattern(r.config.RefreshIndexPattern, r.api, r.logger.Extend("refreshIndexPattern"))
-		r.logger.Infof("Enabled %s, Refreshing %d Pattern(s)", r.refreshIndexPattern.Name(), len(r.refreshIndexPattern.Patterns))
+	// Create scheduler
+	var isLeader func() bool
+	if cfg.LeaderElection.Enabled {
+		isLeader = r.isLeader
+	}
+	s.scheduler = newScheduler(s.context, r.logger.Extend("scheduler"), isLeader, r.store)
+
+	// Init Notifier
+	if cfg.Notify.Enabled {
+		s.notifier = notify.NewNotifier(cfg.Notify, s.api, r.logger.Extend("notify"))
+		s.scheduler.notifier = s.notifier
+		r.logger.Infof("Sending notifications to %d notifier(s)", len(cfg.Notify.Notifiers))
+	}
+
+	// Init Tasks
+	r.initTasks(s, r.logger)
+
+	// Register Tasks
+	err = r.registerTasks(s)
+	if err != nil {
+		s.cancel()
+		return nil, fmt.Errorf("failed to initialize scheduler, error: %s", err.Error())
+	}
+
+	return s, nil
+}
+
+// startState start the scheduler and watchers of a state, they run until the state context is canceled.
+func (r *Rubban) startState(s *state) {
+	s.scheduler.Start()
+
+	// Watch for new indices
+	if s.config.AutoIndexPattern.Enabled && s.config.AutoIndexPattern.Watch.ClusterState {
+		r.logger.Infof("Watching cluster state every %s to trigger %s", s.config.AutoIndexPattern.Watch.Interval, s.autoIndexPattern.Name())
+		go watchClusterState(s.context, s.api, s.config.AutoIndexPattern.Watch.Interval, r.logger.Extend("watcher"), func() {
+			_ = s.scheduler.Trigger(s.autoIndexPattern.Name())
+		})
+	}
+}
+
+// isLeader Return true if this replica is the leader (tasks run on leader only), it's false until leader election starts.
+func (r *Rubban) isLeader() bool {
+	return r.elector != nil && r.elector.IsLeader()
+}
+
+// initTasks create enabled tasks of a state, logging to logger.
+func (r *Rubban) initTasks(s *state, logger log.Logger) {
+
+	if s.config.AutoIndexPattern.Enabled {
+		s.autoIndexPattern = *autoindexpattern.NewAutoIndexPattern(s.config.AutoIndexPattern, s.api, logger.Extend("autoIndexPattern"))
+		logger.Infof("Enabled %s, Loaded %d General Pattern(s)", s.autoIndexPattern.Name(), len(s.autoIndexPattern.GeneralPatterns))
+	}
+
+	if s.config.RefreshIndexPattern.Enabled {
+		s.refreshIndexPattern = *refreshindexpattern.NewRefreshIndexPattern(s.config.RefreshIndexPattern, s.api, logger.Extend("refreshIndexPattern"))
+		logger.Infof("Enabled %s, Refreshing %d Pattern(s)", s.refreshIndexPattern.Name(), len(s.refreshIndexPattern.Patterns))
+	}
+
+	if s.config.DefaultIndexPattern.Enabled {
+		s.defaultIndexPattern = *defaultindexpattern.NewDefaultIndexPattern(s.config.DefaultIndexPattern, s.api, logger.Extend("defaultIndexPattern"))
+		logger.Infof("Enabled %s", s.defaultIndexPattern.Name())
+	}
+
+	if s.config.GitOps.Enabled {
+		s.gitOps = *gitops.NewGitOps(s.config.GitOps, s.api, logger.Extend("gitOps"))
+		logger.Infof("Enabled %s, Syncing Index Patterns from %s", s.gitOps.Name(), s.gitOps.Path)
+	}
+
+	if s.config.ConsolidateIndexPattern.Enabled {
+		s.consolidate = *consolidateindexpattern.NewConsolidateIndexPattern(s.config.ConsolidateIndexPattern, s.api, logger.Extend("consolidateIndexPattern"))
+		logger.Infof("Enabled %s, Merging %s", s.consolidate.Name(), s.consolidate.Merge)
+	}
+
+	if s.config.Backup.Enabled {
+		s.backup = *backup.NewBackup(s.config.Backup, s.api, logger.Extend("backup"))
+		logger.Infof("Enabled %s, Saving Snapshots to %s", s.backup.Name(), s.backup.Path)
 	}
 
 	// ... Init Other Tasks in future
 }
 
-func (r *Rubban) registerTasks() error {
+// enabledTasks Return enabled tasks of a state that change Kibana. (Backup only reads from Kibana)
+func (r *Rubban) enabledTasks(s *state) []Task {
+	tasks := make([]Task, 0)
+	if s.config.AutoIndexPattern.Enabled {
+		tasks = append(tasks, &s.autoIndexPattern)
+	}
+	if s.config.RefreshIndexPattern.Enabled {
+		tasks = append(tasks, &s.refreshIndexPattern)
+	}
+	if s.config.DefaultIndexPattern.Enabled {
+		tasks = append(tasks, &s.defaultIndexPattern)
+	}
+	if s.config.GitOps.Enabled {
+		tasks = append(tasks, &s.gitOps)
+	}
+	if s.config.ConsolidateIndexPattern.Enabled {
+		tasks = append(tasks, &s.consolidate)
+	}
+	return tasks
+}
+
+func (r *Rubban) registerTasks(s *state) error {
 
 	// Register Auto Index Pattern
-	if r.config.AutoIndexPattern.Enabled {
-		err := r.scheduler.Register(r.config.AutoIndexPattern.Schedule, &r.autoIndexPattern)
+	if s.config.AutoIndexPattern.Enabled {
+		err := s.scheduler.Register(s.config.AutoIndexPattern.Schedule, s.config.AutoIndexPattern.TaskRun, &s.autoIndexPattern)
 		if err != nil {
 			return fmt.Errorf("failed to register task, error: %s", err.Error())
 		}
 	}
 
-	if r.config.RefreshIndexPattern.Enabled {
-		err := r.scheduler.Register(r.config.RefreshIndexPattern.Schedule, &r.refreshIndexPattern)
+	if s.config.RefreshIndexPattern.Enabled {
+		err := s.scheduler.Register(s.config.RefreshIndexPattern.Schedule, s.config.RefreshIndexPattern.TaskRun, &s.refreshIndexPattern)
+		if err != nil {
+			return fmt.Errorf("failed to register task, error: %s", err.Error())
+		}
+	}
+
+	if s.config.DefaultIndexPattern.Enabled {
+		err := s.scheduler.Register(s.config.DefaultIndexPattern.Schedule, s.config.DefaultIndexPattern.TaskRun, &s.defaultIndexPattern)
+		if err != nil {
+			return fmt.Errorf("failed to register task, error: %s", err.Error())
+		}
+	}
+
+	if s.config.GitOps.Enabled {
+		err := s.scheduler.Register(s.config.GitOps.Schedule, s.config.GitOps.TaskRun, &s.gitOps)
+		if err != nil {
+			return fmt.Errorf("failed to register task, error: %s", err.Error())
+		}
+	}
+
+	if s.config.ConsolidateIndexPattern.Enabled {
+		err := s.scheduler.Register(s.config.ConsolidateIndexPattern.Schedule, s.config.ConsolidateIndexPattern.TaskRun, &s.consolidate)
+		if err != nil {
+			return fmt.Errorf("failed to register task, error: %s", err.Error())
+		}
+	}
+
+	if s.config.Backup.Enabled {
+		err := s.scheduler.Register(s.config.Backup.Schedule, s.config.Backup.TaskRun, &s.backup)
 		if err != nil {
 			return fmt.Errorf("failed to register task, error: %s", err.Error())
 		}
@@ -131,41 +382,130 @@ func (r *Rubban) registerTasks() error {
 	return nil
 }
 
-func (r *Rubban) initKibanaClient(ctx context.Context) error {
+func (r *Rubban) initServer() {
+	r.server = server.NewServer(r.state.config.HTTP, r.logger.Extend("server"))
+
+	r.server.Handle(http.MethodPost, "/hooks/autoindexpattern", func(w http.ResponseWriter, req *http.Request) {
+		r.mx.RLock()
+		defer r.mx.RUnlock()
+
+		if !r.state.config.AutoIndexPattern.Enabled || !r.state.config.AutoIndexPattern.Watch.Webhook {
+			server.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "webhook is not enabled"})
+			return
+		}
+
+		err := r.state.scheduler.Trigger(r.state.autoIndexPattern.Name())
+		if err != nil {
+			server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		server.WriteJSON(w, http.StatusAccepted, map[string]string{"status": "triggered"})
+	})
+
+	if r.store != nil {
+		r.server.Handle(http.MethodGet, "/runs", r.handleRuns)
+	}
+
+	if r.state.config.HTTP.Admin {
+		r.server.Handle(http.MethodGet, "/tasks", r.handleTasks)
+		r.server.Handle(http.MethodPost, "/tasks/", r.handleTaskAction)
+		r.server.Handle(http.MethodGet, "/plan", r.handlePlan)
+	}
+}
+
+// handleRuns query task runs from store, filters are passed as query params (task, since, title, limit).
+func (r *Rubban) handleRuns(w http.ResponseWriter, req *http.Request) {
+	params := req.URL.Query()
+	query := store.Query{
+		Task:  params.Get("task"),
+		Title: params.Get("title"),
+		Limit: 100,
+	}
+
+	if since := params.Get("since"); since != "" {
+		duration, err := time.ParseDuration(since)
+		if err != nil {
+			server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid since, error: %s", err.Error())})
+			return
+		}
+		query.Since = time.Now().Add(-duration)
+	}
+
+	if limit := params.Get("limit"); limit != "" {
+		var err error
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			server.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid limit, error: %s", err.Error())})
+			return
+		}
+	}
+
+	runs, err := r.store.Runs(req.Context(), query)
+	if err != nil {
+		server.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	server.WriteJSON(w, http.StatusOK, runs)
+}
+
+func (r *Rubban) initKibanaClient(s *state) error {
+	var err error
+	s.api, s.semVer, err = r.newKibanaAPI(s.context, s.config.Kibana)
+	return err
+}
+
+// checkVersion Return an error if configuration uses features that Kibana's version doesn't support. (Index pattern
+// names are only supported by Kibana 8.0 and greater versions)
+func checkVersion(cfg *config.Config, semVer semver.Version) error {
+	ver8, _ := semver.NewVersion("8.0.0")
+	if !cfg.AutoIndexPattern.Enabled || !semVer.LessThan(ver8) {
+		return nil
+	}
+
+	for _, generalPattern := range cfg.AutoIndexPattern.GeneralPatterns {
+		if generalPattern.NameTemplate != "" {
+			return fmt.Errorf("nameTemplate of general pattern [%s] needs Kibana 8.0 or greater, Kibana version is %s",
+				generalPattern.Pattern, semVer.String())
+		}
+	}
+	return nil
+}
+
+// newKibanaAPI Connect to Kibana, and return an API client for its version.
+func (r *Rubban) newKibanaAPI(ctx context.Context, cfg config.Kibana) (kibana.API, semver.Version, error) {
 	r.logger.Info("Initializing Kibana API client...")
-	genAPI, err := kibana.NewAPIGen(r.config.Kibana, r.logger.Extend("api"))
+	genAPI, err := kibana.NewAPIGen(cfg, r.logger.Extend("api"))
 	if err != nil {
-		r.logger.Fatalw("Could not Initialize Kibana API client", "error", err.Error())
-		return errFailedToInitialize
+		r.logger.Errorw("Could not Initialize Kibana API client", "error", err.Error())
+		return nil, semver.Version{}, errFailedToInitialize
 	}
 
 	// Validate Connection to General API (Not versioned yet as we don't have version)
 	if err = genAPI.Validate(ctx); err != nil {
-		r.logger.Fatalw("Cannot Initialize Rubban without an Initial Connection to Kibana API", "error", err.Error())
-		return errFailedToInitialize
+		r.logger.Errorw("Cannot Initialize Rubban without an Initial Connection to Kibana API", "error", err.Error())
+		return nil, semver.Version{}, errFailedToInitialize
 	}
 	r.logger.Info("Validated Initial Connection to Kibana API")
 
 	// Get Kibana Version (To Determine which set of APIs to use later)
-	r.semVer, err = genAPI.GuessVersion(ctx)
+	semVer, err := genAPI.GuessVersion(ctx)
 	if err != nil {
-		r.logger.Fatalw("Couldn't determine kibana version", "error", err.Error())
-		return errFailedToInitialize
+		r.logger.Errorw("Couldn't determine kibana version", "error", err.Error())
+		return nil, semver.Version{}, errFailedToInitialize
 	}
-	r.logger.Infow(fmt.Sprintf("Determined Kibana Version: %s", r.semVer.String()))
+	r.logger.Infow(fmt.Sprintf("Determined Kibana Version: %s", semVer.String()))
 
 	// Determine API
 	ver7, _ := semver.NewVersion("7.0.0")
-	if r.semVer.GreaterThan(ver7) || r.semVer.Equal(ver7) {
-		r.api, err = kibana.NewAPIVer7(r.config.Kibana, r.logger)
+	if semVer.GreaterThan(ver7) || semVer.Equal(ver7) {
+		api, err := kibana.NewAPIVer7(cfg, r.logger)
 		if err != nil {
-			r.logger.Fatalw("Could not Initialize Kibana API client", "error", err.Error())
-			return errFailedToInitialize
+			r.logger.Errorw("Could not Initialize Kibana API client", "error", err.Error())
+			return nil, semver.Version{}, errFailedToInitialize
 		}
-	} else {
-		r.logger.Fatalf("Version %s is not Supported", r.semVer.String())
-		return errFailedToInitialize
+		return api, semVer, nil
 	}
 
-	return nil
+	r.logger.Errorf("Version %s is not Supported", semVer.String())
+	return nil, semver.Version{}, errFailedToInitialize
 }
@@ -2,33 +2,91 @@ package rubban
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"runtime/debug"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/dustin/go-humanize"
 	"github.com/robfig/cron/v3"
+	"github.com/sherifabdlnaby/rubban/config"
 	"github.com/sherifabdlnaby/rubban/log"
+	"github.com/sherifabdlnaby/rubban/rubban/notify"
+	"github.com/sherifabdlnaby/rubban/rubban/store"
+	"go.uber.org/atomic"
 )
 
 type scheduler struct {
-	scheduler  cron.Cron
-	logger     log.Logger
-	context    context.Context
-	specParser cron.Parser
-	tasks      []task
+	scheduler cron.Cron
+	logger    log.Logger
+	context   context.Context
+	isLeader  func() bool
+	store     store.Store
+	notifier  *notify.Notifier
+	tasks     []*task
+	triggered sync.WaitGroup
+	stopped   bool
+	done      chan struct{}
+	mx        sync.Mutex
 }
 
 type task struct {
-	Name  string
-	Entry cron.Entry
+	Name     string
+	Schedule string
+	Entry    cron.Entry
+	options  config.TaskRun
+	job      func()
+	pending  atomic.Bool
+	paused   atomic.Bool
+	mx       sync.Mutex
+	lastRun  *store.Run
+	lastMx   sync.Mutex
 }
 
-func newScheduler(ctx context.Context, logger log.Logger) *scheduler {
+func (t *task) setLastRun(run store.Run) {
+	// Changes are stored, not needed in status.
+	run.Changes = nil
+	t.lastMx.Lock()
+	t.lastRun = &run
+	t.lastMx.Unlock()
+}
+
+func (t *task) getLastRun() *store.Run {
+	t.lastMx.Lock()
+	defer t.lastMx.Unlock()
+	return t.lastRun
+}
+
+//TaskStatus is the status of a registered task.
+type TaskStatus struct {
+	Name     string     `json:"name"`
+	Schedule string     `json:"schedule"`
+	Timezone string     `json:"timezone,omitempty"`
+	Next     time.Time  `json:"next"`
+	Paused   bool       `json:"paused"`
+	LastRun  *store.Run `json:"lastRun,omitempty"`
+}
+
+var (
+	errTaskNotFound     = errors.New("task is not registered")
+	errTaskPaused       = errors.New("task is paused")
+	errSchedulerStopped = errors.New("scheduler is stopped")
+)
+
+//newScheduler Constructor, if isLeader is not nil tasks only run when it returns true, and if store is not nil runs
+//are saved to it.
+func newScheduler(ctx context.Context, logger log.Logger, isLeader func() bool, store store.Store) *scheduler {
 	return &scheduler{
-		scheduler:  *cron.New(),
-		context:    ctx,
-		logger:     logger,
-		specParser: cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor),
-		tasks:      make([]task, 0),
+		scheduler: *cron.New(),
+		context:   ctx,
+		logger:    logger,
+		isLeader:  isLeader,
+		store:     store,
+		tasks:     make([]*task, 0),
+		done:      make(chan struct{}),
 	}
 }
 
@@ -44,7 +102,45 @@ func (s *scheduler) Start() {
 	}
 }
 
+//RunOnStart Trigger tasks registered with runOnStart, each after its start delay.
+func (s *scheduler) RunOnStart() {
+	for _, t := range s.tasks {
+		if !t.options.RunOnStart {
+			continue
+		}
+
+		s.mx.Lock()
+		if s.stopped {
+			s.mx.Unlock()
+			return
+		}
+		s.triggered.Add(1)
+		s.mx.Unlock()
+
+		go func(t *task) {
+			defer s.triggered.Done()
+			select {
+			case <-time.After(t.options.StartDelay):
+			case <-s.done:
+				return
+			}
+
+			err := s.Trigger(t.Name)
+			if err != nil {
+				s.logger.Infof("Not running %s on start, %s", t.Name, err.Error())
+			}
+		}(t)
+	}
+}
+
 func (s *scheduler) Stop() {
+	s.mx.Lock()
+	if !s.stopped {
+		s.stopped = true
+		close(s.done)
+	}
+	s.mx.Unlock()
+
 	ctx := s.scheduler.Stop()
 
 	// Wait for Running Jobs to finish.
@@ -55,38 +151,253 @@ func (s *scheduler) Stop() {
 		s.logger.Infof("Waiting for running jobs to finish...")
 		<-ctx.Done()
 	}
+
+	// Wait for Triggered Jobs to finish.
+	s.triggered.Wait()
 }
 
-func (s *scheduler) Register(spec string, job Task) error {
+//Register Register a task to run on spec's schedule. Overlap policy of options applies to both scheduled and triggered
+//runs, while jitter only delays scheduled runs.
+func (s *scheduler) Register(spec string, options config.TaskRun, job Task) error {
 
-	schedule, err := s.specParser.Parse(spec)
+	schedule, err := config.ParseSchedule(spec, options.Timezone)
 	if err != nil {
 		return err
 	}
 
-	entry := s.scheduler.Schedule(schedule, cron.FuncJob(func() {
+	t := &task{
+		Name:     job.Name(),
+		Schedule: spec,
+		options:  options,
+	}
 
-		defer func() {
-			if r := recover(); r != nil {
-				s.logger.Warnf("Task [%s] PANICKED.", job.Name())
-			}
-		}()
+	run := func() {
+
+		if s.isLeader != nil && !s.isLeader() {
+			s.logger.Debugf("Skipping %s, not the leader.", job.Name())
+			return
+		}
+
+		if t.paused.Load() {
+			s.logger.Debugf("Skipping %s, task is paused.", job.Name())
+			return
+		}
 
 		s.logger.Infof("Running %s...", job.Name())
 		startTime := time.Now()
 
-		job.Run(s.context)
+		recorder := store.NewRecorder(job.Name())
+
+		ctx := store.WithRecorder(s.context, recorder)
+		if options.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+			defer cancel()
+		}
+
+		result := s.runTask(ctx, job)
+
+		if ctx.Err() == context.DeadlineExceeded {
+			s.logger.Warnf("%s timed out after %s", job.Name(), options.Timeout)
+			result.AddError(fmt.Errorf("timed out after %s", options.Timeout))
+		}
+
+		run := recorder.Finish(result)
+		t.setLastRun(run)
+		s.saveRun(run)
+		if s.notifier != nil {
+			s.notifier.Notify(run)
+		}
 
 		next := schedule.Next(time.Now())
-		s.logger.Infof("Finished %s. (took ≈ %dms)", job.Name(), time.Since(startTime).Milliseconds())
+		if result.Failed() {
+			s.logger.Errorw(fmt.Sprintf("Finished %s with %d error(s). (took ≈ %dms)", job.Name(), len(run.Errors), time.Since(startTime).Milliseconds()),
+				"counts", run.Counts, "errors", run.Errors)
+		} else {
+			s.logger.Infow(fmt.Sprintf("Finished %s. (took ≈ %dms)", job.Name(), time.Since(startTime).Milliseconds()), "counts", run.Counts)
+		}
 		s.logger.Infof("Next %s run at %s (%s)", job.Name(), next.String(), humanize.Time(next))
-	}))
+	}
 
-	s.tasks = append(s.tasks, task{
-		Name:  job.Name(),
-		Entry: s.scheduler.Entry(entry),
-	})
+	wrapped := cron.NewChain(s.overlapWrappers(options.Overlap)...).Then(cron.FuncJob(run))
+	entry := s.scheduler.Schedule(schedule, s.withJitter(options.Jitter, wrapped))
+
+	t.Entry = s.scheduler.Entry(entry)
+	t.job = wrapped.Run
+	s.tasks = append(s.tasks, t)
 
 	s.logger.Infof("Registered %s", job.Name())
 	return nil
 }
+
+// runTask Run job, a panic is recovered and returned as an error of the result (and logged with its stack trace).
+func (s *scheduler) runTask(ctx context.Context, job Task) (result store.Result) {
+	defer func() {
+		if r := recover(); r != nil {
+			stack := string(debug.Stack())
+			s.logger.Errorw(fmt.Sprintf("Task [%s] PANICKED.", job.Name()), "panic", fmt.Sprint(r), "stack", stack)
+			result.AddError(fmt.Errorf("panic: %v", r))
+		}
+	}()
+
+	return job.Run(ctx)
+}
+
+// overlapWrappers Return cron job wrappers applying an overlap policy, when a run starts while the previous is still
+// running it's either skipped, delayed until the previous finishes, or allowed to run concurrently.
+func (s *scheduler) overlapWrappers(policy string) []cron.JobWrapper {
+	logger := cronLogger{logger: s.logger}
+	switch policy {
+	case "skip":
+		return []cron.JobWrapper{cron.SkipIfStillRunning(logger)}
+	case "delay":
+		return []cron.JobWrapper{cron.DelayIfStillRunning(logger)}
+	default:
+		return nil
+	}
+}
+
+// withJitter Delay every run of job by a random duration up to jitter (so that replicas and tasks sharing a schedule
+// don't all hit Kibana at the same time), runs waiting for their delay are dropped when scheduler stops.
+func (s *scheduler) withJitter(jitter time.Duration, job cron.Job) cron.Job {
+	if jitter <= 0 {
+		return job
+	}
+
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	mx := sync.Mutex{}
+
+	return cron.FuncJob(func() {
+		mx.Lock()
+		delay := time.Duration(random.Int63n(int64(jitter)))
+		mx.Unlock()
+
+		select {
+		case <-time.After(delay):
+			job.Run()
+		case <-s.done:
+		case <-s.context.Done():
+		}
+	})
+}
+
+func (s *scheduler) saveRun(run store.Run) {
+	if s.store == nil {
+		return
+	}
+
+	// Save even if scheduler's context is canceled, so that runs interrupted by shutdown are recorded.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := s.store.SaveRun(ctx, run)
+	if err != nil {
+		s.logger.Warnw("Failed to save run to store", "task", run.Task, "error", err.Error())
+	}
+}
+
+//Trigger Run a registered task now (outside its schedule). Triggers are coalesced, if a triggered run is already
+//waiting to start, the trigger is a no-op; and triggered runs of the same task never overlap each other.
+func (s *scheduler) Trigger(name string) error {
+	t := s.lookup(name)
+	if t == nil {
+		return errTaskNotFound
+	}
+
+	if t.paused.Load() {
+		return errTaskPaused
+	}
+
+	if !t.pending.CAS(false, true) {
+		s.logger.Debugf("%s is already triggered, skipping...", t.Name)
+		return nil
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.stopped {
+		t.pending.Store(false)
+		return errSchedulerStopped
+	}
+
+	s.triggered.Add(1)
+	go func() {
+		defer s.triggered.Done()
+		t.mx.Lock()
+		defer t.mx.Unlock()
+		t.pending.Store(false)
+		if s.context.Err() != nil {
+			return
+		}
+		s.logger.Infof("Triggered %s", t.Name)
+		t.job()
+	}()
+
+	return nil
+}
+
+//Pause Pause a registered task, its scheduled and triggered runs are skipped until it's resumed.
+func (s *scheduler) Pause(name string) error {
+	t := s.lookup(name)
+	if t == nil {
+		return errTaskNotFound
+	}
+	t.paused.Store(true)
+	s.logger.Infof("Paused %s", t.Name)
+	return nil
+}
+
+//Resume Resume a paused task.
+func (s *scheduler) Resume(name string) error {
+	t := s.lookup(name)
+	if t == nil {
+		return errTaskNotFound
+	}
+	t.paused.Store(false)
+	s.logger.Infof("Resumed %s", t.Name)
+	return nil
+}
+
+//Tasks Return status of registered tasks.
+func (s *scheduler) Tasks() []TaskStatus {
+	tasks := make([]TaskStatus, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		tasks = append(tasks, TaskStatus{
+			Name:     t.Name,
+			Schedule: t.Schedule,
+			Timezone: t.options.Timezone,
+			Next:     t.Entry.Schedule.Next(time.Now()),
+			Paused:   t.paused.Load(),
+			LastRun:  t.getLastRun(),
+		})
+	}
+	return tasks
+}
+
+//lookup Return the registered task with the given name, names are matched ignoring case and spaces so that both
+//"Auto Index Pattern" and "autoIndexPattern" match the same task.
+func (s *scheduler) lookup(name string) *task {
+	for _, t := range s.tasks {
+		if taskKey(t.Name) == taskKey(name) {
+			return t
+		}
+	}
+	return nil
+}
+
+func taskKey(name string) string {
+	return strings.ToLower(strings.Replace(name, " ", "", -1))
+}
+
+// cronLogger adapts Logger to cron's Logger, used by cron's job wrappers.
+type cronLogger struct {
+	logger log.Logger
+}
+
+func (c cronLogger) Info(msg string, keysAndValues ...interface{}) {
+	c.logger.Infow(msg, keysAndValues...)
+}
+
+func (c cronLogger) Error(err error, msg string, keysAndValues ...interface{}) {
+	c.logger.Errorw(msg, append(keysAndValues, "error", err.Error())...)
+}
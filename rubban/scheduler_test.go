@@ -0,0 +1,165 @@
+package rubban
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sherifabdlnaby/rubban/config"
+	"github.com/sherifabdlnaby/rubban/log"
+	"github.com/sherifabdlnaby/rubban/rubban/store"
+)
+
+type mockStore struct {
+	runs []store.Run
+	mx   sync.Mutex
+}
+
+func (m *mockStore) SaveRun(ctx context.Context, run store.Run) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.runs = append(m.runs, run)
+	return nil
+}
+
+func (m *mockStore) Runs(ctx context.Context, query store.Query) ([]store.Run, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return m.runs, nil
+}
+
+func (m *mockStore) Close() error {
+	return nil
+}
+
+type mockTask struct {
+	name string
+	run  func(ctx context.Context) store.Result
+}
+
+func (m *mockTask) Run(ctx context.Context) store.Result {
+	return m.run(ctx)
+}
+
+func (m *mockTask) Name() string {
+	return m.name
+}
+
+func TestSchedulerTimeout(t *testing.T) {
+	runs := &mockStore{}
+	s := newScheduler(context.Background(), log.Default(), nil, runs)
+
+	hung := &mockTask{name: "Hung Task", run: func(ctx context.Context) store.Result {
+		<-ctx.Done()
+		return store.NewResult()
+	}}
+	err := s.Register("@yearly", config.TaskRun{Timeout: 50 * time.Millisecond, Overlap: "delay"}, hung)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Start()
+	if err := s.Trigger("hungTask"); err != nil {
+		t.Fatal(err)
+	}
+	s.Stop()
+
+	result, _ := runs.Runs(context.Background(), store.Query{})
+	if len(result) != 1 || len(result[0].Errors) != 1 || result[0].Errors[0] != "timed out after 50ms" {
+		t.Errorf("Expected a timed out run, got %+v", result)
+	}
+}
+
+func TestSchedulerPause(t *testing.T) {
+	runs := &mockStore{}
+	s := newScheduler(context.Background(), log.Default(), nil, runs)
+
+	task := &mockTask{name: "Task", run: func(ctx context.Context) store.Result {
+		return store.NewResult()
+	}}
+	err := s.Register("@yearly", config.TaskRun{Overlap: "skip"}, task)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Start()
+	if err := s.Pause("task"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Trigger("task"); err != errTaskPaused {
+		t.Errorf("Expected triggering a paused task to fail, got %v", err)
+	}
+	if err := s.Resume("task"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Trigger("task"); err != nil {
+		t.Fatal(err)
+	}
+	s.Stop()
+
+	if err := s.Trigger("task"); err != errSchedulerStopped {
+		t.Errorf("Expected triggering a stopped scheduler to fail, got %v", err)
+	}
+
+	result, _ := runs.Runs(context.Background(), store.Query{})
+	if len(result) != 1 {
+		t.Errorf("Expected 1 run, got %d", len(result))
+	}
+}
+
+func TestSchedulerPanic(t *testing.T) {
+	runs := &mockStore{}
+	s := newScheduler(context.Background(), log.Default(), nil, runs)
+
+	panicking := &mockTask{name: "Panicking Task", run: func(ctx context.Context) store.Result {
+		result := store.NewResult()
+		result.Add(store.ActionCreated, 1)
+		panic("boom")
+	}}
+	err := s.Register("@yearly", config.TaskRun{Overlap: "delay"}, panicking)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Start()
+	if err := s.Trigger("Panicking Task"); err != nil {
+		t.Fatal(err)
+	}
+	s.Stop()
+
+	result, _ := runs.Runs(context.Background(), store.Query{})
+	if len(result) != 1 || len(result[0].Errors) != 1 || result[0].Errors[0] != "panic: boom" {
+		t.Errorf("Expected a failed run, got %+v", result)
+	}
+
+	status := s.Tasks()[0]
+	if status.LastRun == nil || len(status.LastRun.Errors) != 1 {
+		t.Errorf("Expected last run to be failed, got %+v", status.LastRun)
+	}
+}
+
+func TestSchedulerRunOnStart(t *testing.T) {
+	runs := &mockStore{}
+	s := newScheduler(context.Background(), log.Default(), nil, runs)
+
+	for _, name := range []string{"On Start", "Not On Start"} {
+		task := &mockTask{name: name, run: func(ctx context.Context) store.Result {
+			return store.NewResult()
+		}}
+		options := config.TaskRun{Overlap: "delay", RunOnStart: name == "On Start", StartDelay: 10 * time.Millisecond}
+		if err := s.Register("@yearly", options, task); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s.Start()
+	s.RunOnStart()
+	time.Sleep(100 * time.Millisecond)
+	s.Stop()
+
+	result, _ := runs.Runs(context.Background(), store.Query{})
+	if len(result) != 1 || result[0].Task != "On Start" {
+		t.Errorf("Expected 1 run of On Start, got %+v", result)
+	}
+}
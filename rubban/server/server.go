@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/sherifabdlnaby/rubban/config"
+	"github.com/sherifabdlnaby/rubban/log"
+)
+
+//Server is Rubban's HTTP Server, Used to Expose Webhooks and APIs.
+type Server struct {
+	server *http.Server
+	mux    *http.ServeMux
+	token  string
+	logger log.Logger
+}
+
+//NewServer Constructor
+func NewServer(config config.HTTP, logger log.Logger) *Server {
+	mux := http.NewServeMux()
+	return &Server{
+		server: &http.Server{
+			Addr:         config.Address,
+			Handler:      mux,
+			ReadTimeout:  10 * time.Second,
+			WriteTimeout: 30 * time.Second,
+		},
+		mux:    mux,
+		token:  config.Token,
+		logger: logger,
+	}
+}
+
+//Handle Register a handler for the given method and path, requests must be authorized with the configured token
+//(if any) as a Bearer token.
+func (s *Server) Handle(method, path string, handler http.HandlerFunc) {
+	s.mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			WriteJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+
+		if !s.authorized(r) {
+			WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+
+		handler(w, r)
+	})
+}
+
+//ServeHTTP Serve a request with the registered handlers without listening (used by tests)
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+	expected := "Bearer " + s.token
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(expected)) == 1
+}
+
+//Start Start Listening (Non Blocking)
+func (s *Server) Start() {
+	s.logger.Infof("Starting HTTP server at %s", s.server.Addr)
+	go func() {
+		err := s.server.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			s.logger.Errorw("HTTP server stopped unexpectedly", "error", err.Error())
+		}
+	}()
+}
+
+//Stop Stop Server (Will wait for in-flight requests to finish)
+func (s *Server) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := s.server.Shutdown(ctx)
+	if err != nil {
+		s.logger.Warnw("Failed to gracefully stop HTTP server", "error", err.Error())
+	}
+}
+
+//WriteJSON Write a JSON response
+func WriteJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
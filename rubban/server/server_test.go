@@ -0,0 +1,50 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sherifabdlnaby/rubban/config"
+	"github.com/sherifabdlnaby/rubban/log"
+)
+
+func TestHandle(t *testing.T) {
+	for _, tcase := range []struct {
+		name          string
+		token         string
+		method        string
+		authorization string
+		expected      int
+	}{
+		{name: "open server", method: http.MethodPost, expected: http.StatusAccepted},
+		{name: "valid token", token: "secret", method: http.MethodPost, authorization: "Bearer secret", expected: http.StatusAccepted},
+		{name: "missing token", token: "secret", method: http.MethodPost, expected: http.StatusUnauthorized},
+		{name: "wrong token", token: "secret", method: http.MethodPost, authorization: "Bearer wrong", expected: http.StatusUnauthorized},
+		{name: "token without bearer", token: "secret", method: http.MethodPost, authorization: "secret", expected: http.StatusUnauthorized},
+		{name: "wrong method", token: "secret", method: http.MethodGet, authorization: "Bearer secret", expected: http.StatusMethodNotAllowed},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			s := NewServer(config.HTTP{Token: tcase.token}, log.Nop())
+			called := false
+			s.Handle(http.MethodPost, "/hook", func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				WriteJSON(w, http.StatusAccepted, map[string]string{"status": "triggered"})
+			})
+
+			req := httptest.NewRequest(tcase.method, "/hook", nil)
+			if tcase.authorization != "" {
+				req.Header.Set("Authorization", tcase.authorization)
+			}
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, req)
+
+			if w.Code != tcase.expected {
+				t.Errorf("expected status %d, got %d", tcase.expected, w.Code)
+			}
+			if called != (tcase.expected == http.StatusAccepted) {
+				t.Errorf("expected handler to be called only for authorized requests")
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var runsBucket = []byte("runs")
+
+//boltStore is a Store in a local BoltDB file, runs are keyed by their ID which is sortable by start time.
+type boltStore struct {
+	db        *bolt.DB
+	retention time.Duration
+}
+
+func newBoltStore(path string, retention time.Duration) (*boltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(runsBucket)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &boltStore{db: db, retention: retention}, nil
+}
+
+func (b *boltStore) SaveRun(ctx context.Context, run Run) error {
+	buff, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(runsBucket)
+		err := bucket.Put([]byte(run.ID), buff)
+		if err != nil || b.retention <= 0 {
+			return err
+		}
+
+		// Delete runs older than retention (collect keys first, deleting while iterating a cursor skips keys)
+		oldest := runID("", time.Now().Add(-b.retention))
+		expired := make([][]byte, 0)
+		cursor := bucket.Cursor()
+		for k, _ := cursor.First(); k != nil && string(k) < oldest; k, _ = cursor.Next() {
+			expired = append(expired, append([]byte(nil), k...))
+		}
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (b *boltStore) Runs(ctx context.Context, query Query) ([]Run, error) {
+	runs := make([]Run, 0)
+	err := b.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(runsBucket).Cursor()
+		for k, v := cursor.Last(); k != nil; k, v = cursor.Prev() {
+			run := Run{}
+			if err := json.Unmarshal(v, &run); err != nil {
+				return err
+			}
+
+			if !query.Since.IsZero() && run.Started.Before(query.Since) {
+				break
+			}
+
+			if query.Match(run) {
+				runs = append(runs, run)
+				if query.Limit > 0 && len(runs) >= query.Limit {
+					break
+				}
+			}
+		}
+		return nil
+	})
+	return runs, err
+}
+
+func (b *boltStore) Close() error {
+	return b.db.Close()
+}
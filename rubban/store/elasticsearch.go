@@ -0,0 +1,65 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+)
+
+//elasticsearchStore is a Store in an Elasticsearch index (through Kibana's API), a document per run.
+//(Use an ILM policy on the index for retention)
+type elasticsearchStore struct {
+	index string
+	api   kibana.API
+}
+
+func newElasticsearchStore(index string, api kibana.API) *elasticsearchStore {
+	return &elasticsearchStore{index: index, api: api}
+}
+
+func (e *elasticsearchStore) SaveRun(ctx context.Context, run Run) error {
+	return e.api.IndexDocument(ctx, e.index, run.ID, run, nil)
+}
+
+func (e *elasticsearchStore) Runs(ctx context.Context, query Query) ([]Run, error) {
+	filters := make([]interface{}, 0)
+	if query.Task != "" {
+		filters = append(filters, map[string]interface{}{"term": map[string]interface{}{"task.keyword": query.Task}})
+	}
+	if !query.Since.IsZero() {
+		filters = append(filters, map[string]interface{}{"range": map[string]interface{}{"started": map[string]interface{}{"gte": query.Since}}})
+	}
+	if query.Title != "" {
+		filters = append(filters, map[string]interface{}{"term": map[string]interface{}{"changes.title.keyword": query.Title}})
+	}
+
+	size := query.Limit
+	if size <= 0 {
+		size = 10000
+	}
+
+	documents, err := e.api.SearchDocuments(ctx, e.index, map[string]interface{}{
+		"size":  size,
+		"sort":  []interface{}{map[string]interface{}{"started": "desc"}},
+		"query": map[string]interface{}{"bool": map[string]interface{}{"filter": filters}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]Run, 0, len(documents))
+	for _, document := range documents {
+		run := Run{}
+		if err := json.Unmarshal(document, &run); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, nil
+}
+
+func (e *elasticsearchStore) Close() error {
+	return nil
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type recorderKey struct{}
+
+//Recorder collects the changes and errors of a task run, it's passed to tasks through their context.
+type Recorder struct {
+	run Run
+	mx  sync.Mutex
+}
+
+//NewRecorder Constructor
+func NewRecorder(task string) *Recorder {
+	started := time.Now().UTC()
+	return &Recorder{run: Run{
+		ID:      runID(task, started),
+		Task:    task,
+		Started: started,
+	}}
+}
+
+//WithRecorder Return a copy of ctx carrying the recorder.
+func WithRecorder(ctx context.Context, recorder *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, recorder)
+}
+
+//Task Return the name of the task running in ctx (empty if none).
+func Task(ctx context.Context) string {
+	recorder, ok := ctx.Value(recorderKey{}).(*Recorder)
+	if !ok {
+		return ""
+	}
+	return recorder.run.Task
+}
+
+//Record Record changes to the run in ctx (if any).
+func Record(ctx context.Context, changes ...Change) {
+	recorder, ok := ctx.Value(recorderKey{}).(*Recorder)
+	if !ok {
+		return
+	}
+	recorder.mx.Lock()
+	recorder.run.Changes = append(recorder.run.Changes, changes...)
+	recorder.mx.Unlock()
+}
+
+//Finish Return the recorded run with the task's result.
+func (r *Recorder) Finish(result Result) Run {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.run.Finished = time.Now().UTC()
+	r.run.Counts = result.Counts
+	for _, err := range result.Errors {
+		r.run.Errors = append(r.run.Errors, err.Error())
+	}
+	return r.run
+}
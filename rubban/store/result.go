@@ -0,0 +1,32 @@
+package store
+
+//Result is the outcome of a task run returned by the task, counts of what it did (e.g "created") and its errors.
+type Result struct {
+	Counts map[string]int
+	Errors []error
+}
+
+//NewResult Constructor
+func NewResult() Result {
+	return Result{Counts: make(map[string]int)}
+}
+
+//Add Add n to a count
+func (r *Result) Add(name string, n int) {
+	if r.Counts == nil {
+		r.Counts = make(map[string]int)
+	}
+	r.Counts[name] += n
+}
+
+//AddError Add an error
+func (r *Result) AddError(err error) {
+	if err != nil {
+		r.Errors = append(r.Errors, err)
+	}
+}
+
+//Failed Return true if the run had errors
+func (r Result) Failed() bool {
+	return len(r.Errors) > 0
+}
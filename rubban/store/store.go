@@ -0,0 +1,97 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sherifabdlnaby/rubban/config"
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+)
+
+//Actions of a Change
+const (
+	ActionCreated   = "created"
+	ActionRefreshed = "refreshed"
+	ActionUpdated   = "updated"
+	ActionDeleted   = "deleted"
+)
+
+//Store persists task runs and the changes they made to Kibana, so they can be queried after a restart.
+type Store interface {
+	// SaveRun Save a finished run.
+	SaveRun(ctx context.Context, run Run) error
+
+	// Runs Return runs matching query, newest first.
+	Runs(ctx context.Context, query Query) ([]Run, error)
+
+	// Close Close the store.
+	Close() error
+}
+
+//Run is a single run of a task.
+type Run struct {
+	ID       string         `json:"id"`
+	Task     string         `json:"task"`
+	Started  time.Time      `json:"started"`
+	Finished time.Time      `json:"finished"`
+	Counts   map[string]int `json:"counts,omitempty"`
+	Changes  []Change       `json:"changes,omitempty"`
+	Errors   []string       `json:"errors,omitempty"`
+}
+
+//Change is a change made to a Kibana object during a run.
+type Change struct {
+	Action  string   `json:"action"`
+	Type    string   `json:"type"`
+	ID      string   `json:"id,omitempty"`
+	Title   string   `json:"title,omitempty"`
+	Indices []string `json:"indices,omitempty"`
+}
+
+//Query filters runs, zero values match everything.
+type Query struct {
+	Task  string
+	Since time.Time
+	Title string
+	Limit int
+}
+
+//NewStore Constructor
+func NewStore(config config.Store, api kibana.API) (Store, error) {
+	switch config.Backend {
+	case "file":
+		return newBoltStore(config.File.Path, config.Retention)
+	case "elasticsearch":
+		return newElasticsearchStore(config.Elasticsearch.Index, api), nil
+	default:
+		return nil, fmt.Errorf("unknown store backend [%s]", config.Backend)
+	}
+}
+
+func runID(task string, started time.Time) string {
+	return fmt.Sprintf("%019d-%s", started.UnixNano(), strings.ToLower(strings.Replace(task, " ", "-", -1)))
+}
+
+//Match Return true if run matches query (ignoring limit).
+func (q Query) Match(run Run) bool {
+	if q.Task != "" && q.Task != run.Task {
+		return false
+	}
+
+	if !q.Since.IsZero() && run.Started.Before(q.Since) {
+		return false
+	}
+
+	if q.Title != "" {
+		for _, change := range run.Changes {
+			if change.Title == q.Title {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBoltStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rubban-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := newBoltStore(filepath.Join(dir, "rubban.db"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	runs := []Run{
+		{Task: "Auto Index Pattern", Started: now.Add(-2 * time.Hour)},
+		{Task: "Auto Index Pattern", Started: now.Add(-2 * time.Minute), Changes: []Change{{Action: ActionCreated, Type: "index-pattern", Title: "logs-*"}}},
+		{Task: "Refresh Index Pattern", Started: now.Add(-1 * time.Minute), Errors: []string{"failed"}},
+		{Task: "Auto Index Pattern", Started: now, Changes: []Change{{Action: ActionCreated, Type: "index-pattern", Title: "metrics-*"}}},
+	}
+	for _, run := range runs {
+		run.ID = runID(run.Task, run.Started)
+		if err := store.SaveRun(ctx, run); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		query    Query
+		expected []string
+	}{
+		// Oldest run is pruned by retention, newest first.
+		{Query{}, []string{"metrics-*", "", "logs-*"}},
+		{Query{Task: "Auto Index Pattern"}, []string{"metrics-*", "logs-*"}},
+		{Query{Title: "logs-*"}, []string{"logs-*"}},
+		{Query{Since: now.Add(-90 * time.Second)}, []string{"metrics-*", ""}},
+		{Query{Limit: 1}, []string{"metrics-*"}},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%+v", test.query), func(t *testing.T) {
+			result, err := store.Runs(ctx, test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			titles := make([]string, 0)
+			for _, run := range result {
+				title := ""
+				if len(run.Changes) > 0 {
+					title = run.Changes[0].Title
+				}
+				titles = append(titles, title)
+			}
+
+			if fmt.Sprint(titles) != fmt.Sprint(test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, titles)
+			}
+		})
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	recorder := NewRecorder("Auto Index Pattern")
+	ctx := WithRecorder(context.Background(), recorder)
+
+	Record(ctx, Change{Action: ActionCreated, Type: "index-pattern", Title: "logs-*", Indices: []string{"logs-2020"}})
+
+	// Recording without a recorder is a no-op
+	Record(context.Background(), Change{Action: ActionCreated})
+
+	result := NewResult()
+	result.Add(ActionCreated, 1)
+	result.AddError(fmt.Errorf("failed"))
+	result.AddError(nil)
+
+	run := recorder.Finish(result)
+	if len(run.Changes) != 1 || run.Changes[0].Title != "logs-*" {
+		t.Errorf("Expected 1 change, got %v", run.Changes)
+	}
+	if len(run.Errors) != 1 || run.Errors[0] != "failed" {
+		t.Errorf("Expected 1 error, got %v", run.Errors)
+	}
+	if run.Counts[ActionCreated] != 1 {
+		t.Errorf("Expected 1 created, got %v", run.Counts)
+	}
+	if run.Finished.Before(run.Started) {
+		t.Errorf("Expected finished after started")
+	}
+}
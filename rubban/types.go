@@ -2,10 +2,12 @@ package rubban
 
 import (
 	"context"
+
+	"github.com/sherifabdlnaby/rubban/rubban/store"
 )
 
 //Task A Rubban Task that run by the scheduler
 type Task interface {
-	Run(context.Context)
+	Run(context.Context) store.Result
 	Name() string
 }
@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"strings"
+)
+
+//MatchGlob Check if the whole string s matches the wildcard pattern, where `*` matches any sequence of characters.
+func MatchGlob(pattern, s string) bool {
+	p, i := 0, 0
+	star, backtrack := -1, 0
+
+	for i < len(s) {
+		switch {
+		case p < len(pattern) && pattern[p] == '*':
+			// Remember the star, and try matching it with nothing first.
+			star, backtrack = p, i
+			p++
+		case p < len(pattern) && pattern[p] == s[i]:
+			p++
+			i++
+		case star >= 0:
+			// Let the last star consume one more character.
+			backtrack++
+			p, i = star+1, backtrack
+		default:
+			return false
+		}
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+
+	return p == len(pattern)
+}
+
+//GlobSet Match strings against a set of wildcard patterns (Kibana index pattern titles).
+// Patterns are indexed by their literal prefix in a trie, so a string is only checked against patterns whose prefix it
+// starts with, which keeps matching linear to the string length instead of the number of patterns.
+type GlobSet struct {
+	exact map[string]bool
+	root  *globNode
+	size  int
+}
+
+type globNode struct {
+	children map[byte]*globNode
+	// suffixes are the remaining of patterns (starting from the first `*`) whose literal prefix ends at this node.
+	suffixes []string
+}
+
+//NewGlobSet Constructor
+func NewGlobSet(patterns ...string) *GlobSet {
+	g := &GlobSet{
+		exact: make(map[string]bool),
+		root:  &globNode{},
+	}
+	for _, pattern := range patterns {
+		g.Add(pattern)
+	}
+	return g
+}
+
+//Add Add a pattern to the set, a comma separated list of patterns is added as separate patterns.
+func (g *GlobSet) Add(pattern string) {
+	for _, pattern := range strings.Split(pattern, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		g.size++
+
+		star := strings.IndexByte(pattern, '*')
+		if star < 0 {
+			g.exact[pattern] = true
+			continue
+		}
+
+		node := g.root
+		for i := 0; i < star; i++ {
+			if node.children == nil {
+				node.children = make(map[byte]*globNode)
+			}
+			child, ok := node.children[pattern[i]]
+			if !ok {
+				child = &globNode{}
+				node.children[pattern[i]] = child
+			}
+			node = child
+		}
+		node.suffixes = append(node.suffixes, pattern[star:])
+	}
+}
+
+//Len Return number of patterns in the set.
+func (g *GlobSet) Len() int {
+	return g.size
+}
+
+//Match Check if s matches any pattern in the set.
+func (g *GlobSet) Match(s string) bool {
+	if g.exact[s] {
+		return true
+	}
+
+	node := g.root
+	for i := 0; ; i++ {
+		for _, suffix := range node.suffixes {
+			if MatchGlob(suffix, s[i:]) {
+				return true
+			}
+		}
+		if i == len(s) {
+			return false
+		}
+		next, ok := node.children[s[i]]
+		if !ok {
+			return false
+		}
+		node = next
+	}
+}
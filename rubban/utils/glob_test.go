@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		expected   bool
+	}{
+		{"logs-*", "logs-2020.02.14", true},
+		{"logs-*", "logs-", true},
+		{"logs-*", "app-logs-2020.02.14", false},
+		{"*-access-*", "logs-apache-access-2020", true},
+		{"*-access-*", "logs-apache-error-2020", false},
+		{"logs-*-2020", "logs-a-b-2020", true},
+		{"logs-*-2020", "logs-a-b-2021", false},
+		{"**", "", true},
+		{"logs", "logs", true},
+		{"logs", "logs-2020", false},
+	}
+
+	for _, test := range tests {
+		if MatchGlob(test.pattern, test.s) != test.expected {
+			t.Errorf("Expected MatchGlob(%q, %q) to be %v", test.pattern, test.s, test.expected)
+		}
+	}
+}
+
+func TestGlobSet(t *testing.T) {
+	set := NewGlobSet("logs-apache-*", "logs-nginx-*,metrics-*", "*-audit-*", ".kibana", "logs-apache-*")
+
+	tests := []struct {
+		s        string
+		expected bool
+	}{
+		{"logs-apache-2020", true},
+		{"logs-nginx-2020", true},
+		{"metrics-2020", true},
+		{"app-audit-2020", true},
+		{".kibana", true},
+		{".kibana_1", false},
+		{"logs-mysql-2020", false},
+		{"logs-", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		if set.Match(test.s) != test.expected {
+			t.Errorf("Expected Match(%q) to be %v", test.s, test.expected)
+		}
+	}
+
+	if set.Len() != 6 {
+		t.Errorf("Expected 6 patterns, got %d", set.Len())
+	}
+}
+
+// benchmarkData generate patterns of distinct services, and daily indices where every other service has an index pattern.
+func benchmarkData(indices, patterns int) ([]string, []string) {
+	indexNames := make([]string, 0, indices)
+	for i := 0; i < indices; i++ {
+		indexNames = append(indexNames, fmt.Sprintf("logs-service%d-2020.02.%02d", i%(patterns*2), i/(patterns*2)%28+1))
+	}
+	titles := make([]string, 0, patterns)
+	for i := 0; i < patterns; i++ {
+		titles = append(titles, fmt.Sprintf("logs-service%d-*", i*2))
+	}
+	return indexNames, titles
+}
+
+func BenchmarkGlobSet(b *testing.B) {
+	for _, size := range []struct{ indices, patterns int }{{5000, 1000}, {50000, 1000}, {50000, 10000}} {
+		indices, titles := benchmarkData(size.indices, size.patterns)
+		b.Run(fmt.Sprintf("%d indices %d patterns", size.indices, size.patterns), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				set := NewGlobSet(titles...)
+				for _, index := range indices {
+					set.Match(index)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkAlternationRegex is the single alternation regex GlobSet replaces, for comparison.
+func BenchmarkAlternationRegex(b *testing.B) {
+	for _, size := range []struct{ indices, patterns int }{{5000, 100}, {5000, 1000}} {
+		indices, titles := benchmarkData(size.indices, size.patterns)
+		b.Run(fmt.Sprintf("%d indices %d patterns", size.indices, size.patterns), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				regexes := make([]string, 0, len(titles))
+				for _, title := range titles {
+					regexes = append(regexes, PatternToRegex(title))
+				}
+				regex := regexp.MustCompile(strings.Join(regexes, "|"))
+				for _, index := range indices {
+					regex.MatchString(index)
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 )
@@ -19,3 +20,26 @@ func PatternToRegex(s string) string {
 
 	return s
 }
+
+//StringifyMapKeys Convert maps with interface{} keys (as decoded from YAML) to maps with string keys, recursively.
+func StringifyMapKeys(data interface{}) interface{} {
+	switch value := data.(type) {
+	case map[interface{}]interface{}:
+		ret := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			ret[fmt.Sprintf("%v", k)] = StringifyMapKeys(v)
+		}
+		return ret
+	case map[string]interface{}:
+		for k, v := range value {
+			value[k] = StringifyMapKeys(v)
+		}
+		return value
+	case []interface{}:
+		for i, v := range value {
+			value[i] = StringifyMapKeys(v)
+		}
+		return value
+	}
+	return data
+}
@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStringifyMapKeys(t *testing.T) {
+	data := map[interface{}]interface{}{
+		"title": "logs-*",
+		1:       []interface{}{map[interface{}]interface{}{true: "yes"}},
+		"attributes": map[string]interface{}{
+			"fieldFormatMap": map[interface{}]interface{}{"bytes": "number"},
+		},
+	}
+	expected := map[string]interface{}{
+		"title": "logs-*",
+		"1":     []interface{}{map[string]interface{}{"true": "yes"}},
+		"attributes": map[string]interface{}{
+			"fieldFormatMap": map[string]interface{}{"bytes": "number"},
+		},
+	}
+
+	if actual := StringifyMapKeys(data); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+}
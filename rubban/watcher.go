@@ -0,0 +1,37 @@
+package rubban
+
+import (
+	"context"
+	"time"
+
+	"github.com/sherifabdlnaby/rubban/log"
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+)
+
+//watchClusterState Poll Elasticsearch's cluster state version every interval, and call onChange when it changes
+//(e.g a new index is created). Blocks until context is canceled.
+func watchClusterState(ctx context.Context, api kibana.API, interval time.Duration, logger log.Logger, onChange func()) {
+	var lastVersion int64
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		version, err := api.ClusterStateVersion(ctx)
+		if err != nil {
+			logger.Warnw("Failed to get cluster state version", "error", err.Error())
+		} else {
+			if lastVersion != 0 && version != lastVersion {
+				logger.Debugf("Cluster state changed (version %d -> %d)", lastVersion, version)
+				onChange()
+			}
+			lastVersion = version
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
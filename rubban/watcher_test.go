@@ -0,0 +1,62 @@
+package rubban
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sherifabdlnaby/rubban/log"
+	"github.com/sherifabdlnaby/rubban/rubban/kibana"
+)
+
+type mockClusterState struct {
+	kibana.API
+	versions []int64
+	mx       sync.Mutex
+	done     chan struct{}
+}
+
+// ClusterStateVersion return the next version, 0 is returned as an error, and done is closed when all are returned.
+func (m *mockClusterState) ClusterStateVersion(ctx context.Context) (int64, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if len(m.versions) == 0 {
+		return 0, errors.New("no more versions")
+	}
+	version := m.versions[0]
+	m.versions = m.versions[1:]
+	if len(m.versions) == 0 {
+		close(m.done)
+	}
+	if version == 0 {
+		return 0, errors.New("503 Service Unavailable")
+	}
+	return version, nil
+}
+
+func TestWatchClusterState(t *testing.T) {
+	// first version is not a change, failures are skipped, and only changed versions are reported.
+	api := &mockClusterState{versions: []int64{1, 1, 0, 1, 2, 2, 0, 3, 3}, done: make(chan struct{})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	changes := 0
+	go func() {
+		defer close(stopped)
+		watchClusterState(ctx, api, time.Millisecond, log.Nop(), func() { changes++ })
+	}()
+
+	select {
+	case <-api.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for cluster state to be polled")
+	}
+	cancel()
+	<-stopped
+
+	if changes != 2 {
+		t.Errorf("expected 2 changes, got %d", changes)
+	}
+}
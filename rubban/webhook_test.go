@@ -0,0 +1,84 @@
+package rubban
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sherifabdlnaby/rubban/config"
+	"github.com/sherifabdlnaby/rubban/log"
+	"github.com/sherifabdlnaby/rubban/rubban/autoindexpattern"
+	"github.com/sherifabdlnaby/rubban/rubban/store"
+)
+
+func TestWebhook(t *testing.T) {
+	cfg := config.Default()
+	cfg.HTTP.Token = "secret"
+	cfg.AutoIndexPattern.Enabled = true
+	cfg.AutoIndexPattern.Watch.Webhook = true
+
+	autoIndexPattern := autoindexpattern.NewAutoIndexPattern(cfg.AutoIndexPattern, nil, log.Nop())
+	r := New()
+	r.logger = log.Nop()
+	r.state = &state{
+		config:           cfg,
+		scheduler:        newScheduler(context.Background(), log.Nop(), nil, nil),
+		autoIndexPattern: *autoIndexPattern,
+	}
+
+	// The task blocks until released, so triggers sent while it runs are coalesced into a single run after it.
+	var runs int32
+	started, release := make(chan struct{}, 2), make(chan struct{})
+	task := &mockTask{name: autoIndexPattern.Name(), run: func(ctx context.Context) store.Result {
+		atomic.AddInt32(&runs, 1)
+		started <- struct{}{}
+		<-release
+		return store.NewResult()
+	}}
+	if err := r.state.scheduler.Register("@yearly", config.TaskRun{Overlap: "delay"}, task); err != nil {
+		t.Fatal(err)
+	}
+	r.state.scheduler.Start()
+	r.initServer()
+
+	hook := func(token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/hooks/autoindexpattern", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.server.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := hook("wrong"); code != http.StatusUnauthorized {
+		t.Errorf("expected unauthorized webhook to be rejected, got %d", code)
+	}
+
+	if code := hook("secret"); code != http.StatusAccepted {
+		t.Fatalf("expected webhook to be accepted, got %d", code)
+	}
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for triggered run")
+	}
+
+	for i := 0; i < 5; i++ {
+		if code := hook("secret"); code != http.StatusAccepted {
+			t.Fatalf("expected webhook to be accepted, got %d", code)
+		}
+	}
+	close(release)
+	r.state.scheduler.Stop()
+
+	if runs := atomic.LoadInt32(&runs); runs != 2 {
+		t.Errorf("expected triggers to be coalesced into 2 runs, got %d", runs)
+	}
+
+	r.state.config.AutoIndexPattern.Watch.Webhook = false
+	if code := hook("secret"); code != http.StatusNotFound {
+		t.Errorf("expected disabled webhook to respond not found, got %d", code)
+	}
+}